@@ -5,28 +5,39 @@ import (
 	"backend/internal/im"
 	"backend/internal/im/distributor"
 	"backend/internal/im/pusher"
+	"backend/internal/job"
 	"backend/internal/model"
 	"backend/internal/pkg/cache/redis"
 	"backend/internal/pkg/database"
 	"backend/internal/pkg/kafka"
+	"backend/internal/pkg/moderation"
+	"backend/internal/pkg/notify"
+	"backend/internal/pkg/offlinepush"
 	"backend/internal/pkg/prommetrics"
 	"backend/internal/pkg/snowflake"
+	"backend/internal/pkg/webhook"
+	"backend/internal/service"
 	"context"
 	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/goccy/go-yaml"
 )
 
 type AppConfig struct {
-	Redis     redis.Config     `yaml:"redis"`
-	Snowflake snowflake.Config `yaml:"snowflake"`
-	Kafka     kafka.Config     `yaml:"kafka"`
-	Database  database.Config  `yaml:"database"`
-	Server    ServerConfig     `yaml:"server"`
-	WebSocket im.Config        `yaml:"websocket"`
+	Redis       redis.Config       `yaml:"redis"`
+	Snowflake   snowflake.Config   `yaml:"snowflake"`
+	Kafka       kafka.Config       `yaml:"kafka"`
+	Database    database.Config    `yaml:"database"`
+	Server      ServerConfig       `yaml:"server"`
+	WebSocket   im.Config          `yaml:"websocket"`
+	Service     service.Config     `yaml:"service"`
+	Webhook     webhook.Config     `yaml:"webhook"`
+	OfflinePush offlinepush.Config `yaml:"offline_push"`
+	Push        pusher.Config      `yaml:"push"`
 }
 
 type ServerConfig struct {
@@ -44,6 +55,33 @@ func main() {
 	database.Init(cfg.Database)
 	snowflake.Init(cfg.Snowflake)
 	kafka.Init(cfg.Kafka)
+	service.Init(cfg.Service)
+	webhook.Init(cfg.Webhook)
+	if err := offlinepush.Init(cfg.OfflinePush); err != nil {
+		log.Printf("offline push init failed: %v", err)
+	}
+	if err := notify.Init(); err != nil {
+		log.Printf("notify init failed: %v", err)
+	}
+	if p, err := kafka.NewSyncProducer(); err != nil {
+		log.Printf("system msg producer init failed: %v", err)
+	} else {
+		service.InitSystemMsgProducer(service.NewMsgProducer(service.NewMessageService(database.GetDB()), p))
+	}
+
+	if cfg.Service.ModerationWordFile != "" {
+		defaultAction := moderation.ParseAction(cfg.Service.ModerationDefaultAction, moderation.ActionMask)
+		if filter, err := moderation.NewWordFilter(cfg.Service.ModerationWordFile, defaultAction); err != nil {
+			log.Printf("moderation word filter init failed: %v", err)
+		} else {
+			reload := time.Duration(cfg.Service.ModerationReloadSeconds) * time.Second
+			if reload <= 0 {
+				reload = 30 * time.Second
+			}
+			go filter.Watch(context.Background(), reload)
+			service.InitModerator(moderation.Chain{filter})
+		}
+	}
 
 	if os.Getenv("ABD_SILENT") == "1" {
 		log.SetOutput(io.Discard)
@@ -57,20 +95,40 @@ func main() {
 	db.AutoMigrate(&model.Group{})
 	db.AutoMigrate(&model.GroupRequest{})
 	db.AutoMigrate(&model.GroupMember{})
+	db.AutoMigrate(&model.DeviceToken{})
 
 	db.AutoMigrate(&model.Message{})
 	db.AutoMigrate(&model.Conversation{})
 	db.AutoMigrate(&model.SeqConversation{})
 	db.AutoMigrate(&model.SeqUser{})
 	db.AutoMigrate(&model.UserTimeline{})
+	db.AutoMigrate(&model.SeqUserTimeline{})
+	db.AutoMigrate(&model.DeviceCheckpoint{})
+	db.AutoMigrate(&model.MsgAt{})
+	db.AutoMigrate(&model.MsgReaction{})
+	db.AutoMigrate(&model.MsgReactionCount{})
+	db.AutoMigrate(&model.MsgPin{})
+	db.AutoMigrate(&model.MsgSearchIndex{})
+	db.AutoMigrate(&model.ScheduledMessage{})
+	db.AutoMigrate(&model.ConversationSetting{})
+	db.AutoMigrate(&model.MessageArchive{})
+	db.AutoMigrate(&model.MsgModerationRecord{})
 
 	r := api.NewGinRouter()
 	wsServer := im.NewWsServer(cfg.WebSocket)
-	pusher.InitAndRun(wsServer)
+	pusher.InitAndRun(wsServer, cfg.Push)
 	distributor := distributor.NewDistributor(wsServer)
 	go distributor.Start()
 	go wsServer.Run(context.Background())
 
+	if scheduledMsgJob, err := job.NewScheduledMsgJob(); err != nil {
+		log.Printf("scheduled msg job init failed: %v", err)
+	} else {
+		go scheduledMsgJob.Run(context.Background())
+	}
+	go job.NewMsgExpireJob().Run(context.Background())
+	go job.NewMsgRetentionJob().Run(context.Background())
+
 	prommetrics.RegistryAll()
 	go prommetrics.Start(cfg.Server.MetricsAddr)
 
@@ -2,12 +2,20 @@ package errs
 
 // 统一错误码定义
 const (
-	ErrCodeCommon         = 99999 // 通用错误
-	ErrCodeSuccess        = 0
-	ErrCodeInvalidParam   = 10001
-	ErrCodeUnauthorized   = 10002
-	ErrCodeNotFound       = 10003
-	ErrCodeInternalServer = 20001
+	ErrCodeCommon       = 99999 // 通用错误
+	ErrCodeSuccess      = 0
+	ErrCodeInvalidParam = 10001
+	ErrCodeUnauthorized = 10002
+	ErrCodeNotFound     = 10003
+	ErrCodeNoPermission = 10004
+	// 业务回调
+	ErrCodeCallbackRejected = 10005
+	ErrCodeCallbackFailed   = 10006
+	ErrCodeInternalServer   = 20001
+
+	// 业务回调拒绝时可以返回的自定义错误码范围，范围外的统一转为 ErrCodeCallbackRejected
+	ErrCodeCallbackCustomBegin = 50001
+	ErrCodeCallbackCustomEnd   = 59999
 
 	// 用户相关
 	ErrCodeUserNotFound      = 11001
@@ -21,6 +29,8 @@ const (
 	ErrCodeFriendRequestSent     = 12003
 	ErrCodeFriendRequestNotFound = 12004
 	ErrCodeFriendBlocked         = 12005
+	ErrCodeBlockedByPeer         = 12006
+	ErrCodeNotFriend             = 12007
 
 	// 群组相关
 	ErrCodeGroupNotFound            = 13001
@@ -33,15 +43,32 @@ const (
 	ErrCodeGroupOnlyOwnerCanSetRole = 13008
 	ErrCodeGroupRoleLevelTooHigh    = 13009
 	ErrCodeGroupQuitSelfOnly        = 13010
+	ErrCodeGroupRequestNotFound     = 13011
+	ErrCodeGroupMuted               = 13012
+	ErrCodeGroupMemberMuted         = 13013
+
+	// 消息相关
+	ErrCodeMsgNotFound              = 14001
+	ErrCodeNotConversationMember    = 14002
+	ErrCodeGroupReadReceiptDisabled = 14003
+	ErrCodeAtAllPermissionDenied    = 14004
+	ErrCodeReactionLimit            = 14005
+	ErrCodeMsgContentInvalid        = 14006
+	ErrCodeScheduledMsgNotPending   = 14007
+	ErrCodeMsgPinLimit              = 14008
+	ErrCodeMsgBlocked               = 14009
 )
 
 // 常用错误变量
 var (
-	ErrCommon         = NewCodeError(ErrCodeCommon, "通用错误")
-	ErrInvalidParam   = NewCodeError(ErrCodeInvalidParam, "参数错误")
-	ErrUnauthorized   = NewCodeError(ErrCodeUnauthorized, "未授权")
-	ErrNotFound       = NewCodeError(ErrCodeNotFound, "资源未找到")
-	ErrInternalServer = NewCodeError(ErrCodeInternalServer, "服务器内部错误")
+	ErrCommon           = NewCodeError(ErrCodeCommon, "通用错误")
+	ErrInvalidParam     = NewCodeError(ErrCodeInvalidParam, "参数错误")
+	ErrUnauthorized     = NewCodeError(ErrCodeUnauthorized, "未授权")
+	ErrNotFound         = NewCodeError(ErrCodeNotFound, "资源未找到")
+	ErrNoPermission     = NewCodeError(ErrCodeNoPermission, "无权限")
+	ErrCallbackRejected = NewCodeError(ErrCodeCallbackRejected, "操作被拒绝")
+	ErrCallbackFailed   = NewCodeError(ErrCodeCallbackFailed, "业务回调失败")
+	ErrInternalServer   = NewCodeError(ErrCodeInternalServer, "服务器内部错误")
 
 	// 用户相关
 	ErrUserNotFound      = NewCodeError(ErrCodeUserNotFound, "用户不存在")
@@ -55,6 +82,8 @@ var (
 	ErrFriendRequestSent     = NewCodeError(ErrCodeFriendRequestSent, "好友请求已发送")
 	ErrFriendRequestNotFound = NewCodeError(ErrCodeFriendRequestNotFound, "好友请求不存在")
 	ErrFriendBlocked         = NewCodeError(ErrCodeFriendBlocked, "好友已被拉黑")
+	ErrBlockedByPeer         = NewCodeError(ErrCodeBlockedByPeer, "对方已将你拉黑")
+	ErrNotFriend             = NewCodeError(ErrCodeNotFriend, "对方不是你的好友")
 
 	// 群组相关
 	ErrGroupNotFound            = NewCodeError(ErrCodeGroupNotFound, "群组不存在")
@@ -67,6 +96,20 @@ var (
 	ErrGroupOnlyOwnerCanSetRole = NewCodeError(ErrCodeGroupOnlyOwnerCanSetRole, "只有群主可以调整角色等级")
 	ErrGroupRoleLevelTooHigh    = NewCodeError(ErrCodeGroupRoleLevelTooHigh, "不能将角色设置为高于自身的等级")
 	ErrGroupQuitSelfOnly        = NewCodeError(ErrCodeGroupQuitSelfOnly, "只能退出自己的群成员关系")
+	ErrGroupRequestNotFound     = NewCodeError(ErrCodeGroupRequestNotFound, "入群申请不存在或已处理")
+	ErrGroupMuted               = NewCodeError(ErrCodeGroupMuted, "群聊已开启全员禁言")
+	ErrGroupMemberMuted         = NewCodeError(ErrCodeGroupMemberMuted, "你已被禁言")
+
+	// 消息相关
+	ErrMsgNotFound              = NewCodeError(ErrCodeMsgNotFound, "消息不存在")
+	ErrNotConversationMember    = NewCodeError(ErrCodeNotConversationMember, "不是会话成员")
+	ErrGroupReadReceiptDisabled = NewCodeError(ErrCodeGroupReadReceiptDisabled, "群人数超过上限，不支持消息已读状态")
+	ErrAtAllPermissionDenied    = NewCodeError(ErrCodeAtAllPermissionDenied, "只有群主和管理员可以@所有人")
+	ErrReactionLimit            = NewCodeError(ErrCodeReactionLimit, "消息表情回应种类已达上限")
+	ErrMsgContentInvalid        = NewCodeError(ErrCodeMsgContentInvalid, "消息内容不合法")
+	ErrScheduledMsgNotPending   = NewCodeError(ErrCodeScheduledMsgNotPending, "定时消息已发送或已取消")
+	ErrMsgPinLimit              = NewCodeError(ErrCodeMsgPinLimit, "会话置顶消息数已达上限")
+	ErrMsgBlocked               = NewCodeError(ErrCodeMsgBlocked, "消息包含违规内容")
 )
 
 // CodeError 结构体和构造函数
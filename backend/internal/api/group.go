@@ -141,6 +141,27 @@ func (a *GroupApi) KickGroupMember(c *gin.Context) {
 	apiresp.GinSuccess(c, gin.H{"msg": "已移除群成员"})
 }
 
+func (a *GroupApi) RespondGroupApplication(c *gin.Context) {
+	var req service.RespondGroupApplicationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	requestID, err := strconv.ParseUint(c.Param("requestID"), 10, 64)
+	if err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.GroupID = c.Param("id")
+	req.RequestID = uint(requestID)
+	req.OperatorUserID = c.GetInt64("user_id")
+	if err := a.s.RespondGroupApplication(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
 func (a *GroupApi) DismissGroup(c *gin.Context) {
 	var req service.DismissGroupReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -192,3 +213,75 @@ func (a *GroupApi) SetGroupMemberInfo(c *gin.Context) {
 	}
 	apiresp.GinSuccess(c, gin.H{"msg": "群成员信息已更新"})
 }
+
+func (a *GroupApi) MuteGroupMember(c *gin.Context) {
+	var req service.MuteGroupMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.GroupID = c.Param("id")
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = userID
+	req.OperatorUserID = c.GetInt64("user_id")
+	if err := a.s.MuteGroupMember(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, gin.H{"msg": "已禁言该成员"})
+}
+
+func (a *GroupApi) CancelMuteGroupMember(c *gin.Context) {
+	var req service.CancelMuteGroupMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.GroupID = c.Param("id")
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = userID
+	req.OperatorUserID = c.GetInt64("user_id")
+	if err := a.s.CancelMuteGroupMember(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, gin.H{"msg": "已解除禁言"})
+}
+
+func (a *GroupApi) MuteGroup(c *gin.Context) {
+	var req service.MuteGroupReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.GroupID = c.Param("id")
+	req.OperatorUserID = c.GetInt64("user_id")
+	if err := a.s.MuteGroup(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, gin.H{"msg": "已开启全员禁言"})
+}
+
+func (a *GroupApi) CancelMuteGroup(c *gin.Context) {
+	var req service.MuteGroupReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.GroupID = c.Param("id")
+	req.OperatorUserID = c.GetInt64("user_id")
+	if err := a.s.CancelMuteGroup(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, gin.H{"msg": "已关闭全员禁言"})
+}
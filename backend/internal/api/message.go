@@ -3,17 +3,19 @@ package api
 import (
 	"backend/internal/api/apiresp"
 	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/constant"
 	"backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MessageApi struct {
-	s *service.MessageService
+	s        *service.MessageService
+	producer *service.MsgProducer
 }
 
-func NewMessageApi(s *service.MessageService) *MessageApi {
-	return &MessageApi{s: s}
+func NewMessageApi(s *service.MessageService, producer *service.MsgProducer) *MessageApi {
+	return &MessageApi{s: s, producer: producer}
 }
 
 func (a *MessageApi) SendMessage(c *gin.Context) {
@@ -23,12 +25,20 @@ func (a *MessageApi) SendMessage(c *gin.Context) {
 		return
 	}
 	req.SenderID = c.GetInt64("user_id")
-	err := a.s.SendMessage(c.Request.Context(), req)
+	// HTTP 请求没有长连接，发送结果直接在响应里返回
+	req.SenderConnID = ""
+	// 通知会话只能由后台接口发送
+	if req.ConvType == constant.NotificationChatType {
+		apiresp.GinError(c, errs.ErrInvalidParam.WithDetail("invalid conv type"))
+		return
+	}
+	// 和 WebSocket 发送走同一条路径：校验、审核、回调后投递 Kafka
+	resp, err := a.producer.Send(c.Request.Context(), &req)
 	if err != nil {
 		apiresp.GinError(c, err)
 		return
 	}
-	apiresp.GinSuccess(c, nil)
+	apiresp.GinSuccess(c, resp)
 }
 
 func (a *MessageApi) PullSpecifiedConv(c *gin.Context) {
@@ -71,3 +81,309 @@ func (a *MessageApi) DeleteConversation(c *gin.Context) {
 	}
 	apiresp.GinSuccess(c, nil)
 }
+
+func (a *MessageApi) SetConversationHasReadSeq(c *gin.Context) {
+	var req service.SetConversationHasReadSeqReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.SetConversationHasReadSeq(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) GetGroupMsgReadState(c *gin.Context) {
+	var req service.GetGroupMsgReadStateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetGroupMsgReadState(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) GetMsgThread(c *gin.Context) {
+	var req service.GetMsgThreadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetMsgThread(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) GetMsgReplyCounts(c *gin.Context) {
+	var req service.GetMsgReplyCountsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetMsgReplyCounts(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) AddMsgReaction(c *gin.Context) {
+	var req service.ReactMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.AddMsgReaction(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) RemoveMsgReaction(c *gin.Context) {
+	var req service.ReactMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.RemoveMsgReaction(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) SetConversationMsgTTL(c *gin.Context) {
+	var req service.SetConversationMsgTTLReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.SetConversationMsgTTL(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) SetConversationRetention(c *gin.Context) {
+	var req service.SetConversationRetentionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.SetConversationRetention(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) SetDeviceCheckpoint(c *gin.Context) {
+	var req service.SetDeviceCheckpointReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.SetDeviceCheckpoint(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) GetDeviceCheckpoint(c *gin.Context) {
+	var req service.GetDeviceCheckpointReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetDeviceCheckpoint(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) PinMsg(c *gin.Context) {
+	var req service.PinMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.PinMsg(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) UnpinMsg(c *gin.Context) {
+	var req service.PinMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.UnpinMsg(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) GetPinnedMsgs(c *gin.Context) {
+	var req service.GetPinnedMsgsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetPinnedMsgs(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) SearchMsg(c *gin.Context) {
+	var req service.SearchMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.SearchMsg(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) CreateScheduledMessage(c *gin.Context) {
+	var req service.CreateScheduledMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.SenderID = c.GetInt64("user_id")
+	resp, err := a.s.CreateScheduledMessage(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) GetScheduledMessages(c *gin.Context) {
+	var req service.GetScheduledMsgsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.GetScheduledMessages(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) UpdateScheduledMessage(c *gin.Context) {
+	var req service.UpdateScheduledMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.s.UpdateScheduledMessage(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) CancelScheduledMessage(c *gin.Context) {
+	var req service.CancelScheduledMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.CancelScheduledMessage(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *MessageApi) ForwardMsg(c *gin.Context) {
+	var req service.ForwardMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	resp, err := a.producer.ForwardMsg(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) BatchSendMsg(c *gin.Context) {
+	var req service.BatchSendMsgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.SenderID = c.GetInt64("user_id")
+	resp, err := a.producer.BatchSendMsg(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
+
+func (a *MessageApi) SendBusinessNotification(c *gin.Context) {
+	var req service.SendBusinessNotificationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.SenderID = c.GetInt64("user_id")
+	resp, err := a.producer.SendBusinessNotification(c.Request.Context(), req)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, resp)
+}
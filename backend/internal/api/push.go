@@ -0,0 +1,45 @@
+package api
+
+import (
+	"backend/internal/api/apiresp"
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PushApi struct {
+	s *service.PushService
+}
+
+func NewPushApi(s *service.PushService) *PushApi {
+	return &PushApi{s: s}
+}
+
+func (a *PushApi) RegisterDeviceToken(c *gin.Context) {
+	var req service.RegisterDeviceTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.RegisterDeviceToken(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
+
+func (a *PushApi) UnregisterDeviceToken(c *gin.Context) {
+	var req service.UnregisterDeviceTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrInvalidParam)
+		return
+	}
+	req.UserID = c.GetInt64("user_id")
+	if err := a.s.UnregisterDeviceToken(c.Request.Context(), req); err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	apiresp.GinSuccess(c, nil)
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"backend/docs"
+	"backend/internal/pkg/kafka"
 	"backend/internal/service"
+	"log"
 	"time"
 
 	"backend/internal/pkg/database"
@@ -34,7 +36,13 @@ func NewGinRouter() *gin.Engine {
 	u := NewUserApi(userService)
 	f := NewFriendApi(service.NewFriendService(database.GetDB(), userService))
 	g := NewGroupApi(service.NewGroupService(database.GetDB()))
-	m := NewMessageApi(service.NewMessageService(database.GetDB()))
+	p := NewPushApi(service.NewPushService(database.GetDB()))
+	messageService := service.NewMessageService(database.GetDB())
+	producer, err := kafka.NewSyncProducer()
+	if err != nil {
+		log.Printf("router: failed to create kafka producer: %v", err)
+	}
+	m := NewMessageApi(messageService, service.NewMsgProducer(messageService, producer))
 
 	public := r.Group("/user")
 	{
@@ -68,40 +76,69 @@ func NewGinRouter() *gin.Engine {
 
 		groupRouterGroup := auth.Group("/groups")
 		{
-			groupRouterGroup.POST("", g.CreateGroup)                                // 创建群组
-			groupRouterGroup.GET("", g.GetGroupsInfo)                               // 获取群组信息
-			groupRouterGroup.GET("/:id/members", g.GetGroupMemberList)              // 获取群成员列表
-			groupRouterGroup.POST("/:id/join", g.JoinGroup)                         // 加入群组/申请进群
-			groupRouterGroup.DELETE("/:id/members/:userID", g.QuitGroup)            // 退出群组
-			groupRouterGroup.POST("/:id/invitations", g.InviteUserToGroup)          // 邀请进群
-			groupRouterGroup.DELETE("/:id/members/:userID/kick", g.KickGroupMember) // 踢人
-			groupRouterGroup.DELETE("/:id", g.DismissGroup)                         // 解散群组
-			groupRouterGroup.POST("/:id", g.SetGroupInfo)                           // 设置群信息
-			groupRouterGroup.POST("/:id/members/:userID", g.SetGroupMemberInfo)     // 设置群成员信息
+			groupRouterGroup.POST("", g.CreateGroup)                                         // 创建群组
+			groupRouterGroup.GET("", g.GetGroupsInfo)                                        // 获取群组信息
+			groupRouterGroup.GET("/:id/members", g.GetGroupMemberList)                       // 获取群成员列表
+			groupRouterGroup.POST("/:id/join", g.JoinGroup)                                  // 加入群组/申请进群
+			groupRouterGroup.POST("/:id/applications/:requestID", g.RespondGroupApplication) // 处理入群申请
+			groupRouterGroup.DELETE("/:id/members/:userID", g.QuitGroup)                     // 退出群组
+			groupRouterGroup.POST("/:id/invitations", g.InviteUserToGroup)                   // 邀请进群
+			groupRouterGroup.DELETE("/:id/members/:userID/kick", g.KickGroupMember)          // 踢人
+			groupRouterGroup.DELETE("/:id", g.DismissGroup)                                  // 解散群组
+			groupRouterGroup.POST("/:id", g.SetGroupInfo)                                    // 设置群信息
+			groupRouterGroup.POST("/:id/members/:userID", g.SetGroupMemberInfo)              // 设置群成员信息
+			groupRouterGroup.POST("/:id/members/:userID/mute", g.MuteGroupMember)            // 禁言成员
+			groupRouterGroup.POST("/:id/members/:userID/unmute", g.CancelMuteGroupMember)    // 解除成员禁言
+			groupRouterGroup.POST("/:id/mute", g.MuteGroup)                                  // 开启全员禁言
+			groupRouterGroup.POST("/:id/unmute", g.CancelMuteGroup)                          // 关闭全员禁言
+		}
+
+		pushRouterGroup := auth.Group("/push")
+		{
+			pushRouterGroup.POST("/token/register", p.RegisterDeviceToken)     // 上报设备推送 token
+			pushRouterGroup.POST("/token/unregister", p.UnregisterDeviceToken) // 删除设备推送 token
 		}
 
 		// Message
 		msgGroup := auth.Group("/msg")
 		{
-			msgGroup.POST("/send", m.SendMessage)              // 发送消息
-			msgGroup.GET("/pull", m.PullConvList)              // 拉取会话列表
-			msgGroup.GET("/pull/:convID", m.PullSpecifiedConv) // 拉取某个会话的消息
+			msgGroup.POST("/send", m.SendMessage)                        // 发送消息
+			msgGroup.GET("/pull", m.PullConvList)                        // 拉取会话列表
+			msgGroup.GET("/pull/:convID", m.PullSpecifiedConv)           // 拉取某个会话的消息
+			msgGroup.POST("/read", m.SetConversationHasReadSeq)          // 上报会话已读位点
+			msgGroup.POST("/read-state", m.GetGroupMsgReadState)         // 群消息已读/未读成员
+			msgGroup.POST("/thread", m.GetMsgThread)                     // 话题根消息及回复
+			msgGroup.POST("/reply-counts", m.GetMsgReplyCounts)          // 批量查询回复数
+			msgGroup.POST("/reaction/add", m.AddMsgReaction)             // 添加表情回应
+			msgGroup.POST("/reaction/remove", m.RemoveMsgReaction)       // 取消表情回应
+			msgGroup.POST("/forward", m.ForwardMsg)                      // 逐条/合并转发消息
+			msgGroup.POST("/pin", m.PinMsg)                              // 置顶消息
+			msgGroup.POST("/unpin", m.UnpinMsg)                          // 取消置顶
+			msgGroup.POST("/pins", m.GetPinnedMsgs)                      // 会话置顶消息列表
+			msgGroup.POST("/search", m.SearchMsg)                        // 消息搜索
+			msgGroup.POST("/scheduled/create", m.CreateScheduledMessage) // 创建定时消息
+			msgGroup.POST("/scheduled/list", m.GetScheduledMessages)     // 定时消息列表
+			msgGroup.POST("/scheduled/update", m.UpdateScheduledMessage) // 修改定时消息
+			msgGroup.POST("/scheduled/cancel", m.CancelScheduledMessage) // 取消定时消息
+			msgGroup.POST("/conv-ttl/set", m.SetConversationMsgTTL)      // 设置会话消息默认存活时间
+			msgGroup.POST("/retention/set", m.SetConversationRetention)  // 设置群消息保留天数
+			msgGroup.POST("/checkpoint/set", m.SetDeviceCheckpoint)      // 上报设备同步进度
+			msgGroup.POST("/checkpoint/get", m.GetDeviceCheckpoint)      // 获取设备同步进度
+			admin := msgGroup.Group("", AdminMiddleware(userService))
+			admin.POST("/batch-send", m.BatchSendMsg)                             // 批量发送消息
+			admin.POST("/send-business-notification", m.SendBusinessNotification) // 发送业务通知
 			// msgGroup.POST("/newest-seq", m.GetSeq)
-			// msgGroup.POST("/search", m.SearchMsg)
 			// msgGroup.POST("/send", m.SendMessage)
-			// msgGroup.POST("/send-business-notification", m.SendBusinessNotification)
 			// msgGroup.POST("/pull", m.PullMsgBySeqs)
 			// msgGroup.POST("/revoke", m.RevokeMsg)
 			// msgGroup.POST("/mark-read", m.MarkMsgsAsRead)
 			// msgGroup.POST("/sync-convs", m.GetConversationsHasReadAndMaxSeq)
-			// msgGroup.POST("/read", m.SetConversationHasReadSeq)
 
 			// msgGroup.POST("/clear-conv", m.ClearConversationsMsg)
 			// msgGroup.POST("/clear-all", m.UserClearAllMsg)
 			// msgGroup.POST("/delete", m.DeleteMsgs)
 			// msgGroup.POST("/delete-physical", m.DeleteMsgPhysical)
 
-			// msgGroup.POST("/batch-send", m.BatchSendMsg)
 			// msgGroup.POST("/server-time", m.GetServerTime)
 		}
 
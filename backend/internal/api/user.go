@@ -85,6 +85,24 @@ func (a *UserApi) UserLogin(c *gin.Context) {
 	apiresp.GinSuccess(c, token)
 }
 
+// AdminMiddleware 要求当前用户为应用管理员，需放在 AuthMiddleware 之后
+func AdminMiddleware(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, err := userService.IsAppManager(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			apiresp.GinError(c, err)
+			c.Abort()
+			return
+		}
+		if !ok {
+			apiresp.GinError(c, errs.ErrNoPermission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取 Authorization Header
@@ -126,6 +126,7 @@ type GroupMemberInfo struct {
 	AvatarURL string    `json:"avatarURL"`
 	RoleLevel int32     `json:"roleLevel"`
 	JoinedAt  time.Time `json:"joinedAt"`
+	MuteEndAt time.Time `json:"muteEndAt"`
 }
 
 func ConvertToGroupMemberInfo(member model.GroupMember) GroupMemberInfo {
@@ -137,5 +138,6 @@ func ConvertToGroupMemberInfo(member model.GroupMember) GroupMemberInfo {
 		AvatarURL: member.AvatarURL,
 		RoleLevel: member.RoleLevel,
 		JoinedAt:  member.JoinedAt,
+		MuteEndAt: member.MuteEndAt,
 	}
 }
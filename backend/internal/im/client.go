@@ -26,7 +26,9 @@ type Client struct {
 	req        *http.Request
 
 	PlatformID int
+	DeviceID   string
 	UserID     int64
+	ConnID     string // 连接唯一标识，用于把发送结果回推给发起的连接
 	IsCompress bool
 	Encoder
 
@@ -40,6 +42,12 @@ type Client struct {
 	hbCancel context.CancelFunc
 }
 
+var connSeq atomic.Uint64
+
+func newConnID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(connSeq.Add(1), 36)
+}
+
 // typed context keys to avoid collisions
 type ctxKey string
 
@@ -58,6 +66,7 @@ func (c *Client) ResetClient(respWriter http.ResponseWriter, req *http.Request,
 	c.server = wsServer
 	// parse URL parameters
 	c.PlatformID, _ = strconv.Atoi(req.URL.Query().Get(PlatformID))
+	c.DeviceID = req.URL.Query().Get(DeviceID)
 	c.IsCompress = req.URL.Query().Get(Compression) == GzipCompressionProtocol
 	c.token = req.URL.Query().Get(Token)
 	var err error
@@ -68,6 +77,7 @@ func (c *Client) ResetClient(respWriter http.ResponseWriter, req *http.Request,
 		c.close()
 		return
 	}
+	c.ConnID = newConnID()
 	c.closed.Store(false)
 	c.hbCtx, c.hbCancel = context.WithCancel(req.Context())
 
@@ -164,7 +174,7 @@ func (c *Client) handleMessage(b []byte) error {
 		}
 	}
 
-	binaryReq := getReq(c.token, c.UserID)
+	binaryReq := getReq(c.token, c.UserID, c.ConnID, c.DeviceID)
 	defer freeReq(binaryReq)
 
 	if err := c.Encoder.Decode(b, &binaryReq.InboundReq); err != nil {
@@ -209,6 +219,15 @@ func (c *Client) handleMessage(b []byte) error {
 	case WsPullConvLastMessage:
 		log.Printf("获取会话最后一条消息")
 		resp, err = c.server.GetLastMessage(ctx, binaryReq)
+	case WSSetConvHasReadSeq:
+		log.Printf("上报会话已读序列号")
+		resp, err = c.server.SetConversationHasReadSeq(ctx, binaryReq)
+	case WSSetDeviceCheckpoint:
+		log.Printf("上报设备同步进度")
+		resp, err = c.server.SetDeviceCheckpoint(ctx, binaryReq)
+	case WSGetDeviceCheckpoint:
+		log.Printf("获取设备同步进度")
+		resp, err = c.server.GetDeviceCheckpoint(ctx, binaryReq)
 	// case WsLogoutMsg:
 	// 	resp, err = c.server.UserLogout(ctx, binaryReq)
 	// case WsSubUserOnlineStatus:
@@ -296,6 +315,27 @@ func (c *Client) PushMessage(ctx context.Context, msg any) error {
 	return c.writeBinaryMsg(resp)
 }
 
+// PushSignal 推送不占用 seq 的在线信令（已读数变化等）
+func (c *Client) PushSignal(ctx context.Context, sig any) error {
+	resp := Resp{
+		ReqIdentifier: WSPushSignal,
+		Data:          sig,
+	}
+	return c.writeBinaryMsg(resp)
+}
+
+// PushSendResult 回推消息发送结果，MsgIncr 与发送请求一致，失败时 Code 非 0
+func (c *Client) PushSendResult(ctx context.Context, msgIncr string, code int, msg string, result any) error {
+	resp := Resp{
+		ReqIdentifier: WSPushSendResult,
+		MsgIncr:       msgIncr,
+		Code:          code,
+		Msg:           msg,
+		Data:          result,
+	}
+	return c.writeBinaryMsg(resp)
+}
+
 func (c *Client) activeHeartbeat(ctx context.Context) {
 	if c.PlatformID == WebPlatformID {
 		go func() {
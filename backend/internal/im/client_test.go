@@ -202,3 +202,14 @@ func TestClient_KickOnlineMessage(t *testing.T) {
 	// Verify connection is closed?
 	// client.close() calls conn.Close()
 }
+
+func TestNewConnIDUnique(t *testing.T) {
+	seen := make(map[string]struct{}, 1000)
+	for i := 0; i < 1000; i++ {
+		id := newConnID()
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicated conn id %s", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
@@ -6,6 +6,7 @@ const (
 	// Websocket URL parameters
 	WsUserID                = "sendID"
 	PlatformID              = "platformID"
+	DeviceID                = "deviceID" // 区分同步进度，未传时不读写设备进度
 	Token                   = "token"
 	Compression             = "compression" //compression == "gzip" means use gzip compression
 	GzipCompressionProtocol = "gzip"
@@ -30,11 +31,16 @@ const (
 	WSPullMsg             = 1005
 	WSGetConvMaxReadSeq   = 1006
 	WsPullConvLastMessage = 1007
+	WSSetConvHasReadSeq   = 1008
+	WSSetDeviceCheckpoint = 1009
+	WSGetDeviceCheckpoint = 1010
 	WSPushMsg             = 2001
 	WSKickOnlineMsg       = 2002
 	WsLogoutMsg           = 2003
 	WsSetBackgroundStatus = 2004
 	WsSubUserOnlineStatus = 2005
+	WSPushSignal          = 2006
+	WSPushSendResult      = 2007
 	WSDataError           = 3001
 	WSTest                = 4001
 )
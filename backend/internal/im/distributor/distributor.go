@@ -8,25 +8,33 @@ import (
 	"backend/internal/pkg/cache/redis"
 	"backend/internal/pkg/database"
 	"backend/internal/pkg/kafka"
-	"backend/internal/pkg/snowflake"
 	"backend/internal/service"
 	"context"
 	"encoding/json"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/IBM/sarama"
 )
 
+const (
+	// 时间线写入失败时的重试次数和间隔，事务整体回滚，重试不会重复分配 seq
+	appendTimelineRetry    = 5
+	appendTimelineInterval = 200 * time.Millisecond
+)
+
 type Distributor struct {
-	wsServer *im.WsServer
-	repo     *imrepo.ImRepo
+	wsServer   *im.WsServer
+	repo       *imrepo.ImRepo
+	msgService *service.MessageService
 }
 
 func NewDistributor(wsServer *im.WsServer) *Distributor {
 	return &Distributor{
-		wsServer: wsServer,
-		repo:     imrepo.NewImRepo(database.GetDB(), redis.GetRDB()),
+		wsServer:   wsServer,
+		repo:       imrepo.NewImRepo(database.GetDB(), redis.GetRDB()),
+		msgService: service.NewMessageService(database.GetDB()),
 	}
 }
 
@@ -60,10 +68,14 @@ func (d *Distributor) Start() {
 			3. 存储消息到数据库
 		*/
 		convID := service.GetConversationID(msgs[0].ConvType, msgs[0].SenderID, msgs[0].TargetID)
+		msgs = d.dedupMsgs(ctx, msgs)
+		if len(msgs) == 0 {
+			return
+		}
 		var msgsToStore []*model.Message
 		for _, msgReq := range msgs {
 			msg := &model.Message{
-				ID:             snowflake.GenID(),
+				ID:             msgReq.ServerMsgID,
 				ConversationID: convID,
 				SenderID:       msgReq.SenderID,
 				MsgType:        msgReq.MsgType,
@@ -72,6 +84,17 @@ func (d *Distributor) Start() {
 				TargetID:       msgReq.TargetID,
 				ClientMsgID:    msgReq.ClientMsgID,
 				SendTime:       time.Now().UnixMilli(),
+				AtUserIDs:      msgReq.AtUserIDs,
+				IsAtAll:        msgReq.IsAtAll,
+				RefMsgID:       msgReq.RefMsgID,
+				RootMsgID:      msgReq.RootMsgID,
+				RefMsg:         msgReq.RefMsg,
+				TTL:            msgReq.TTL,
+				BurnAfterRead:  msgReq.BurnAfterRead,
+				RecvIDs:        msgReq.RecvIDs,
+			}
+			if msg.TTL > 0 && !msg.BurnAfterRead {
+				msg.ExpireAt = msg.SendTime + msg.TTL*1000
 			}
 			msgsToStore = append(msgsToStore, msg)
 		}
@@ -79,7 +102,14 @@ func (d *Distributor) Start() {
 		isNewConversation, err := d.repo.BatchStoreMsgToRedis(ctx, convID, msgsToStore)
 		if err != nil {
 			log.Printf("distributor: BatchStoreMsgToRedis error: %v", err)
+			// seq 未能可靠分配，整批失败，释放去重登记让客户端重试
+			for _, msgReq := range msgs {
+				d.msgService.ReleaseSendMessage(ctx, msgReq)
+				service.PushSendResult(ctx, msgReq, nil, err)
+			}
+			return
 		}
+		d.msgService.CompleteSendMessage(ctx, msgsToStore)
 
 		if isNewConversation {
 			log.Printf("distributor: new conversation created: %s", convID)
@@ -92,8 +122,20 @@ func (d *Distributor) Start() {
 			go d.repo.InvalidateConversationIDsCache(context.Background(), initReq)
 		}
 
-		// 2. 存储消息到数据库
-		go d.repo.BatchStoreMsgToDB(context.Background(), msgsToStore)
+		// 2. 存储消息到数据库，落库后向发送连接回推结果
+		go func() {
+			ctx := context.Background()
+			err := d.repo.BatchStoreMsgToDB(ctx, msgsToStore)
+			if err != nil {
+				log.Printf("distributor: BatchStoreMsgToDB error: %v", err)
+			}
+			for i, msgReq := range msgs {
+				service.PushSendResult(ctx, msgReq, msgsToStore[i], err)
+			}
+			if err == nil {
+				d.appendUserTimelines(ctx, msgsToStore)
+			}
+		}()
 
 		// 2. 发送消息给在线用户
 		if onlinePushProducer != nil {
@@ -134,6 +176,45 @@ func (d *Distributor) Start() {
 	batchprocessor.Start()
 }
 
+// dedupMsgs 按发送者 + ClientMsgID 去掉批次内及已处理过的重复消息
+// appendUserTimelines 消息已落库，时间线写入失败时按间隔重试，仍失败时记录消息ID以便补写
+func (d *Distributor) appendUserTimelines(ctx context.Context, msgs []*model.Message) {
+	var err error
+	for i := 1; i <= appendTimelineRetry; i++ {
+		if err = d.msgService.AppendUserTimelines(ctx, msgs); err == nil {
+			return
+		}
+		log.Printf("distributor: AppendUserTimelines error: %v, attempt: %d", err, i)
+		time.Sleep(time.Duration(i) * appendTimelineInterval)
+	}
+	msgIDs := make([]int64, 0, len(msgs))
+	for _, msg := range msgs {
+		msgIDs = append(msgIDs, msg.ID)
+	}
+	log.Printf("distributor: AppendUserTimelines retrying still failed, msgIDs: %v, err: %v", msgIDs, err)
+}
+
+func (d *Distributor) dedupMsgs(ctx context.Context, msgs []*service.SendMessageReq) []*service.SendMessageReq {
+	seen := make(map[string]struct{}, len(msgs))
+	result := msgs[:0]
+	for _, msg := range msgs {
+		if msg.ClientMsgID != "" {
+			key := strconv.FormatInt(msg.SenderID, 10) + ":" + msg.ClientMsgID
+			if _, ok := seen[key]; ok {
+				log.Printf("distributor: duplicated message in batch, senderID=%d clientMsgID=%s", msg.SenderID, msg.ClientMsgID)
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		if !d.msgService.ClaimSendMessage(ctx, msg) {
+			log.Printf("distributor: duplicated message, senderID=%d clientMsgID=%s", msg.SenderID, msg.ClientMsgID)
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
 type msgHandler struct {
 	fn func(*service.SendMessageReq) error
 }
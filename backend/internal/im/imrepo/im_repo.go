@@ -8,6 +8,7 @@ import (
 	"backend/internal/service"
 	"context"
 	"encoding/json"
+	"log"
 	"strconv"
 	"time"
 
@@ -16,7 +17,10 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-const msgCacheTimeout = time.Hour * 24
+const (
+	msgCacheTimeout     = time.Hour * 24
+	invalidateBatchSize = 500
+)
 
 type ImRepo struct {
 	db         *gorm.DB
@@ -54,7 +58,7 @@ func (r *ImRepo) BatchStoreMsgToRedis(ctx context.Context, conversationID string
 			return isNewConversation, err
 		}
 		// 将 Set 命令加入管道，注意这里不会立即执行
-		pipe.Set(ctx, cachekey.GetMsgCacheKey(conversationID, msg.Seq), string(data), msgCacheTimeout)
+		pipe.Set(ctx, cachekey.GetMsgCacheKey(conversationID, msg.Seq), string(data), msgCacheExpire(msg))
 	}
 
 	// 一次性执行管道中的所有命令
@@ -62,14 +66,65 @@ func (r *ImRepo) BatchStoreMsgToRedis(ctx context.Context, conversationID string
 	return isNewConversation, err
 
 }
+
+// msgCacheExpire 会过期的消息缓存不超过消息本身的存活时间
+func msgCacheExpire(msg *model.Message) time.Duration {
+	if msg.ExpireAt == 0 {
+		return msgCacheTimeout
+	}
+	d := time.Until(time.UnixMilli(msg.ExpireAt))
+	if d <= 0 {
+		return time.Second
+	}
+	if d > msgCacheTimeout {
+		return msgCacheTimeout
+	}
+	return d
+}
+
 func (r *ImRepo) BatchStoreMsgToDB(ctx context.Context, msgs []*model.Message) error {
 	if len(msgs) == 0 {
 		return nil
 	}
-	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}}, // clientID
 		DoUpdates: clause.AssignmentColumns([]string{"content", "send_time"}),
-	}).Create(msgs).Error
+	}).Create(msgs).Error; err != nil {
+		return err
+	}
+	if err := r.batchStoreMsgAts(ctx, msgs); err != nil {
+		return err
+	}
+	return r.batchStoreSearchIndex(ctx, msgs)
+}
+
+// batchStoreMsgAts 记录@明细，@所有人记为 AtUserID=0 的一行
+func (r *ImRepo) batchStoreMsgAts(ctx context.Context, msgs []*model.Message) error {
+	var ats []*model.MsgAt
+	for _, msg := range msgs {
+		if msg.IsAtAll {
+			ats = append(ats, &model.MsgAt{MsgID: msg.ID, ConversationID: msg.ConversationID, Seq: msg.Seq, SenderID: msg.SenderID})
+		}
+		for _, userID := range msg.AtUserIDs {
+			ats = append(ats, &model.MsgAt{MsgID: msg.ID, ConversationID: msg.ConversationID, AtUserID: userID, Seq: msg.Seq, SenderID: msg.SenderID})
+		}
+	}
+	if len(ats) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(ats, 100).Error
+}
+
+// batchStoreSearchIndex 为可检索的消息写入倒排索引
+func (r *ImRepo) batchStoreSearchIndex(ctx context.Context, msgs []*model.Message) error {
+	var indices []*model.MsgSearchIndex
+	for _, msg := range msgs {
+		indices = append(indices, service.BuildSearchIndex(msg)...)
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(indices, 200).Error
 }
 
 func (r *ImRepo) BatchGetMsg(ctx context.Context, key string, start, end int64) ([]string, error) {
@@ -89,8 +144,25 @@ func (r *ImRepo) InvalidateConversationIDsCache(ctx context.Context, req service
 		for _, owner := range owners {
 			_ = r.rdb.Del(ctx, cachekey.GetConversationIDsKey(strconv.FormatInt(owner, 10))).Err()
 		}
+	case constant.NotificationChatType:
+		_ = r.rdb.Del(ctx, cachekey.GetConversationIDsKey(strconv.FormatInt(req.TargetID, 10))).Err()
 	case constant.GroupChatType:
-		// TODO: 获取群成员并批量删除缓存，先留空以免阻塞。
+		var memberIDs []int64
+		if err := r.db.WithContext(ctx).Model(&model.GroupMember{}).
+			Where("group_id = ?", strconv.FormatInt(req.TargetID, 10)).
+			Pluck("user_id", &memberIDs).Error; err != nil {
+			log.Printf("imrepo: get group %d member ids error: %v", req.TargetID, err)
+			return
+		}
+		// 大群分批删除，避免单条命令过大
+		for start := 0; start < len(memberIDs); start += invalidateBatchSize {
+			end := min(start+invalidateBatchSize, len(memberIDs))
+			keys := make([]string, 0, end-start)
+			for _, memberID := range memberIDs[start:end] {
+				keys = append(keys, cachekey.GetConversationIDsKey(strconv.FormatInt(memberID, 10)))
+			}
+			_ = r.rdb.Del(ctx, keys...).Err()
+		}
 	default:
 	}
 }
@@ -1,8 +1,8 @@
 package im
 
 import (
-	"backend/internal/pkg/kafka"
-	"backend/internal/pkg/prommetrics"
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/constant"
 	"backend/internal/service"
 	"context"
 	"encoding/json"
@@ -30,8 +30,10 @@ type InboundReq struct {
 
 type Req struct {
 	InboundReq
-	Token  string
-	SendID int64
+	Token    string
+	SendID   int64
+	ConnID   string
+	DeviceID string
 }
 
 var reqPool = sync.Pool{
@@ -40,13 +42,15 @@ var reqPool = sync.Pool{
 	},
 }
 
-func getReq(token string, sendId int64) *Req {
+func getReq(token string, sendId int64, connID string, deviceID string) *Req {
 	req := reqPool.Get().(*Req)
 	req.Data = nil
 	req.MsgIncr = ""
 	req.ReqIdentifier = 0
 	req.SendID = sendId
 	req.Token = token
+	req.ConnID = connID
+	req.DeviceID = deviceID
 	return req
 }
 func freeReq(req *Req) {
@@ -74,26 +78,29 @@ type MessageHandler interface {
 	GetConversationsHasReadAndMaxSeq(ctx context.Context, data *Req) (any, error)
 	GetSeqMessage(ctx context.Context, data *Req) (any, error)
 	GetLastMessage(ctx context.Context, data *Req) (any, error)
+	SetConversationHasReadSeq(ctx context.Context, data *Req) (any, error)
+	SetDeviceCheckpoint(ctx context.Context, data *Req) (any, error)
+	GetDeviceCheckpoint(ctx context.Context, data *Req) (any, error)
 }
 
 var _ MessageHandler = (*ServiceHandler)(nil)
 
 type ServiceHandler struct {
 	messageService *service.MessageService
-	producer       sarama.SyncProducer
+	msgProducer    *service.MsgProducer
 	// pushClient *
 }
 
 func NewServiceHandler(messageService *service.MessageService, producer sarama.SyncProducer) *ServiceHandler {
 	return &ServiceHandler{
 		messageService: messageService,
-		producer:       producer,
+		msgProducer:    service.NewMsgProducer(messageService, producer),
 	}
 }
 
 func (s *ServiceHandler) GetSeq(ctx context.Context, data *Req) (any, error) {
 	log.Printf("GetSeq request: %d", data.SendID)
-	resp, err := s.messageService.GetMaxSeq(ctx, data.SendID)
+	resp, err := s.messageService.GetMaxSeq(ctx, data.SendID, data.DeviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -105,35 +112,29 @@ func (s *ServiceHandler) GetSeq(ctx context.Context, data *Req) (any, error) {
 func (s *ServiceHandler) SendMessage(ctx context.Context, data *Req) (any, error) {
 	// encode
 	log.Printf("SendMessage: %+v", data)
-	// var sendMsgReq service.SendMessageReq
-	// if err := json.Unmarshal(data.Data, &sendMsgReq); err != nil {
-	// 	return nil, err
-	// }
-	// err := s.messageService.SendMessage(ctx, sendMsgReq)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// return nil, nil
-	msg := &sarama.ProducerMessage{
-		Topic: kafka.ComingMessageTopic,
-		Value: sarama.ByteEncoder(data.Data),
-	}
-
-	partition, offset, err := s.producer.SendMessage(msg)
-	if err != nil {
-		prommetrics.MsgProcessFailedCounter.Inc()
-		log.Printf("FAILED to send kafka message: %v", err)
+	var sendMsgReq service.SendMessageReq
+	if err := json.Unmarshal(data.Data, &sendMsgReq); err != nil {
 		return nil, err
 	}
-	prommetrics.MsgProcessSuccessCounter.Inc()
-	log.Printf("message sent to partition=%d offset=%d", partition, offset)
-	return nil, nil
+	// 发送者以连接鉴权得到的用户为准，不信任客户端上报的 sender_id
+	sendMsgReq.SenderID = data.SendID
+	// 分发完成后按连接和 MsgIncr 回推发送结果
+	sendMsgReq.SenderConnID = data.ConnID
+	sendMsgReq.MsgIncr = data.MsgIncr
+	// 通知会话只能由后台接口发送
+	if sendMsgReq.ConvType == constant.NotificationChatType {
+		return nil, errs.ErrInvalidParam.WithDetail("invalid conv type")
+	}
+	return s.msgProducer.Send(ctx, &sendMsgReq)
 }
 func (s *ServiceHandler) PullMessageBySeqList(ctx context.Context, data *Req) (any, error) {
 	var pullReq service.PullMessageBySeqsReq
 	if err := json.Unmarshal(data.Data, &pullReq); err != nil {
 		return nil, err
 	}
+	if pullReq.DeviceID == "" {
+		pullReq.DeviceID = data.DeviceID
+	}
 	log.Printf("PullMessageBySeqList request: %+v", pullReq)
 	resp, err := s.messageService.PullMessageBySeqs(ctx, data.SendID, pullReq)
 	if err != nil {
@@ -188,6 +189,44 @@ func (s *ServiceHandler) GetSeqMessage(ctx context.Context, data *Req) (any, err
 	return resp, nil
 }
 
+func (s *ServiceHandler) SetConversationHasReadSeq(ctx context.Context, data *Req) (any, error) {
+	var setReq service.SetConversationHasReadSeqReq
+	if err := json.Unmarshal(data.Data, &setReq); err != nil {
+		return nil, err
+	}
+	setReq.UserID = data.SendID
+	log.Printf("SetConversationHasReadSeq request: %+v", setReq)
+	if err := s.messageService.SetConversationHasReadSeq(ctx, setReq); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *ServiceHandler) SetDeviceCheckpoint(ctx context.Context, data *Req) (any, error) {
+	var setReq service.SetDeviceCheckpointReq
+	if err := json.Unmarshal(data.Data, &setReq); err != nil {
+		return nil, err
+	}
+	setReq.UserID = data.SendID
+	setReq.DeviceID = data.DeviceID
+	log.Printf("SetDeviceCheckpoint request: %+v", setReq)
+	if err := s.messageService.SetDeviceCheckpoint(ctx, setReq); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *ServiceHandler) GetDeviceCheckpoint(ctx context.Context, data *Req) (any, error) {
+	var getReq service.GetDeviceCheckpointReq
+	if err := json.Unmarshal(data.Data, &getReq); err != nil {
+		return nil, err
+	}
+	getReq.UserID = data.SendID
+	getReq.DeviceID = data.DeviceID
+	log.Printf("GetDeviceCheckpoint request: %+v", getReq)
+	return s.messageService.GetDeviceCheckpoint(ctx, getReq)
+}
+
 func (s *ServiceHandler) PullSpecifiedConv(ctx context.Context, data *Req) (any, error) {
 	var pullReq service.PullSpecifiedConvReq
 	if err := json.Unmarshal(data.Data, &pullReq); err != nil {
@@ -206,6 +245,9 @@ func (s *ServiceHandler) PullConvList(ctx context.Context, data *Req) (any, erro
 	if err := json.Unmarshal(data.Data, &pullReq); err != nil {
 		return nil, err
 	}
+	if pullReq.DeviceID == "" {
+		pullReq.DeviceID = data.DeviceID
+	}
 	log.Printf("PullConvList request: %+v", pullReq)
 	resp, err := s.messageService.PullConvList(ctx, pullReq)
 	if err != nil {
@@ -58,6 +58,7 @@ func TestServiceHandler_SendMessage(t *testing.T) {
 			ReqIdentifier: 1001,
 			Data:          dataBytes,
 		},
+		SendID: sendReq.SenderID,
 	}
 
 	// Test SendMessage
@@ -0,0 +1,28 @@
+package pusher
+
+// Config 在线推送配置
+type Config struct {
+	// 群成员数超过该值时只推送新 seq 信令，客户端收到后自行拉取消息
+	GroupFullMsgMaxMembers int `yaml:"group_full_msg_max_members"`
+	// 群消息按批并发推送，每批成员数和同时推送的批数
+	FanoutBatchSize   int `yaml:"fanout_batch_size"`
+	FanoutConcurrency int `yaml:"fanout_concurrency"`
+}
+
+var conf = Config{
+	GroupFullMsgMaxMembers: 500,
+	FanoutBatchSize:        200,
+	FanoutConcurrency:      8,
+}
+
+func applyConfig(cfg Config) {
+	if cfg.GroupFullMsgMaxMembers > 0 {
+		conf.GroupFullMsgMaxMembers = cfg.GroupFullMsgMaxMembers
+	}
+	if cfg.FanoutBatchSize > 0 {
+		conf.FanoutBatchSize = cfg.FanoutBatchSize
+	}
+	if cfg.FanoutConcurrency > 0 {
+		conf.FanoutConcurrency = cfg.FanoutConcurrency
+	}
+}
@@ -0,0 +1,102 @@
+package pusher
+
+import (
+	"backend/internal/im"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/notify"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// NewSeqElem 大群新消息信令的内容
+type NewSeqElem struct {
+	Seq      int64 `json:"seq"`
+	SenderID int64 `json:"sender_id,string"`
+	SendTime int64 `json:"send_time"`
+}
+
+// pushGroupMessage 按批并发推送群消息。成员数超过阈值时只推送新 seq 信令，
+// 避免万人群每条消息都把完整内容写给所有连接。没有在线连接的成员走离线推送
+func (p *Pusher) pushGroupMessage(ctx context.Context, msg *model.Message) error {
+	memberIDs := msg.RecvIDs
+	if len(memberIDs) == 0 {
+		var err error
+		memberIDs, err = p.group.GetGroupMemberIDs(ctx, strconv.FormatInt(msg.TargetID, 10))
+		if err != nil {
+			log.Printf("get group %d member ids failed: %v", msg.TargetID, err)
+			return err
+		}
+	}
+	push := func(c *im.Client) error { return c.PushMessage(ctx, msg) }
+	if len(memberIDs) > conf.GroupFullMsgMaxMembers {
+		sig, err := newSeqSignal(msg)
+		if err != nil {
+			return err
+		}
+		push = func(c *im.Client) error { return c.PushSignal(ctx, sig) }
+	}
+
+	var (
+		mu         sync.Mutex
+		offlineIDs []int64
+	)
+	var g errgroup.Group
+	g.SetLimit(conf.FanoutConcurrency)
+	for _, batch := range splitBatches(memberIDs, conf.FanoutBatchSize) {
+		g.Go(func() error {
+			var offline []int64
+			for _, memberID := range batch {
+				clients, ok := p.wsServer.Clients.GetAll(memberID)
+				if !ok {
+					if memberID != msg.SenderID {
+						offline = append(offline, memberID)
+					}
+					continue
+				}
+				for _, c := range clients {
+					if err := push(c); err != nil {
+						log.Printf("push group message to user %d failed: %v", memberID, err)
+					}
+				}
+			}
+			mu.Lock()
+			offlineIDs = append(offlineIDs, offline...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	p.offline.Push(ctx, msg, offlineIDs)
+	return nil
+}
+
+func newSeqSignal(msg *model.Message) (*notify.Signal, error) {
+	data, err := json.Marshal(NewSeqElem{Seq: msg.Seq, SenderID: msg.SenderID, SendTime: msg.SendTime})
+	if err != nil {
+		return nil, err
+	}
+	return &notify.Signal{
+		Type:           constant.MsgTypeNewSeq,
+		ConversationID: msg.ConversationID,
+		Data:           data,
+	}, nil
+}
+
+// splitBatches 把成员按 size 切分成多批，最后一批可能不足 size
+func splitBatches(ids []int64, size int) [][]int64 {
+	if size <= 0 {
+		size = len(ids)
+	}
+	var batches [][]int64
+	for start := 0; start < len(ids); start += size {
+		end := min(start+size, len(ids))
+		batches = append(batches, ids[start:end])
+	}
+	return batches
+}
@@ -0,0 +1,39 @@
+package pusher
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSplitBatches(t *testing.T) {
+	ids := []int64{1, 2, 3, 4, 5}
+	if got := splitBatches(ids, 2); !reflect.DeepEqual(got, [][]int64{{1, 2}, {3, 4}, {5}}) {
+		t.Fatalf("splitBatches(5, 2) = %v", got)
+	}
+	if got := splitBatches(ids, 0); len(got) != 1 || len(got[0]) != 5 {
+		t.Fatalf("splitBatches(5, 0) = %v", got)
+	}
+	if got := splitBatches(nil, 2); len(got) != 0 {
+		t.Fatalf("splitBatches(nil) = %v", got)
+	}
+}
+
+func TestNewSeqSignal(t *testing.T) {
+	sig, err := newSeqSignal(&model.Message{ConversationID: "group:9", Seq: 42, SenderID: 7, SendTime: 1000, Content: `{"content":"hi"}`})
+	if err != nil {
+		t.Fatalf("newSeqSignal: %v", err)
+	}
+	if sig.Type != constant.MsgTypeNewSeq || sig.ConversationID != "group:9" {
+		t.Fatalf("signal = %+v", sig)
+	}
+	var elem NewSeqElem
+	if err := json.Unmarshal(sig.Data, &elem); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if elem != (NewSeqElem{Seq: 42, SenderID: 7, SendTime: 1000}) {
+		t.Fatalf("elem = %+v", elem)
+	}
+}
@@ -0,0 +1,74 @@
+package pusher
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/offlinepush"
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+const offlinePushTimeout = 10 * time.Second
+
+// offlinePusher 给没有在线连接的接收者发送离线推送，短时间内的多条消息按会话合并
+type offlinePusher struct {
+	push      *service.PushService
+	collapser *offlinepush.Collapser
+}
+
+func newOfflinePusher(push *service.PushService, window time.Duration) *offlinePusher {
+	o := &offlinePusher{push: push}
+	o.collapser = offlinepush.NewCollapser(window, o.send)
+	return o
+}
+
+func (o *offlinePusher) Push(ctx context.Context, msg *model.Message, userIDs []int64) {
+	if !offlinepush.Enabled() || len(userIDs) == 0 {
+		return
+	}
+	userIDs, err := o.push.FilterOfflinePushUsers(ctx, msg, userIDs)
+	if err != nil {
+		log.Printf("offline push filter users failed: %v", err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	n, err := o.push.BuildOfflineNotification(ctx, msg)
+	if err != nil {
+		log.Printf("offline push build notification failed: %v", err)
+		return
+	}
+	for _, userID := range userIDs {
+		o.collapser.Add(userID, n)
+	}
+}
+
+// send 按通道分组推送到用户的所有设备，失效的 token 直接删除
+func (o *offlinePusher) send(userID int64, n *offlinepush.Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), offlinePushTimeout)
+	defer cancel()
+	deviceTokens, err := o.push.GetDeviceTokens(ctx, userID)
+	if err != nil {
+		log.Printf("offline push get device tokens of user %d failed: %v", userID, err)
+		return
+	}
+	byProvider := make(map[string][]string)
+	for _, t := range deviceTokens {
+		byProvider[t.Provider] = append(byProvider[t.Provider], t.Token)
+	}
+	for name, tokens := range byProvider {
+		provider, ok := offlinepush.GetProvider(name)
+		if !ok {
+			continue
+		}
+		invalid, err := provider.Push(ctx, tokens, n)
+		if err != nil {
+			log.Printf("offline push to user %d via %s failed: %v", userID, name, err)
+		}
+		if err := o.push.DeleteDeviceTokens(ctx, name, invalid); err != nil {
+			log.Printf("offline push delete invalid tokens failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package pusher
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/offlinepush"
+	"backend/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOfflinePusher(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.Group{}, &model.Conversation{}, &model.DeviceToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	push := service.NewPushService(db)
+	ctx := context.Background()
+	db.Create(&model.User{UserID: 1, Username: "alice", Nickname: "Alice"})
+	push.RegisterDeviceToken(ctx, service.RegisterDeviceTokenReq{UserID: 2, DeviceID: "phone", Provider: "mock", Token: "t2"})
+	push.RegisterDeviceToken(ctx, service.RegisterDeviceTokenReq{UserID: 2, DeviceID: "pad", Provider: "mock", Token: "stale"})
+
+	mock := offlinepush.NewMockProvider("mock")
+	mock.SetInvalid("stale")
+	offlinepush.Register(mock)
+	defer offlinepush.Unregister("mock")
+	offlinepush.SetEnabled(true)
+	defer offlinepush.SetEnabled(false)
+
+	o := newOfflinePusher(push, time.Hour)
+	for i := int64(1); i <= 3; i++ {
+		o.Push(ctx, &model.Message{ID: i, ConversationID: "single:1_2", Seq: i, SenderID: 1, ConvType: constant.SingleChatType,
+			TargetID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"hi"}`}, []int64{2})
+	}
+	o.collapser.Flush()
+
+	pushes := mock.Pushes()
+	if len(pushes) != 1 || len(pushes[0].Tokens) != 2 {
+		t.Fatalf("pushes = %+v", pushes)
+	}
+	if n := pushes[0].Notification; n.Title != "Alice" || n.Body != "[3条] hi" || n.Data["seq"] != "3" {
+		t.Fatalf("notification = %+v", n)
+	}
+	if tokens, _ := push.GetDeviceTokens(ctx, 2); len(tokens) != 1 || tokens[0].Token != "t2" {
+		t.Fatalf("stale token not removed: %+v", tokens)
+	}
+}
@@ -6,23 +6,29 @@ import (
 	"backend/internal/pkg/constant"
 	"backend/internal/pkg/database"
 	"backend/internal/pkg/kafka"
+	"backend/internal/pkg/notify"
+	"backend/internal/pkg/offlinepush"
 	"backend/internal/service"
 	"context"
+	"encoding/json"
 	"log"
-	"strconv"
 )
 
 type Pusher struct {
 	wsServer *im.WsServer
 	group    *service.GroupService
+	offline  *offlinePusher
 }
 
-func InitAndRun(wsServer *im.WsServer) {
+func InitAndRun(wsServer *im.WsServer, cfg Config) {
+	applyConfig(cfg)
 	pusher := Pusher{
 		wsServer: wsServer,
 		group:    service.NewGroupService(database.GetDB()),
+		offline:  newOfflinePusher(service.NewPushService(database.GetDB()), offlinepush.CollapseWindow()),
 	}
 	go pusher.PushMessageToUser()
+	go pusher.PushSignalToUser()
 }
 
 func (p *Pusher) PushMessageToUser() error {
@@ -44,6 +50,8 @@ func (p *Pusher) PushMessageToUser() error {
 			clients := make(map[int64][]*im.Client, 2)
 			if targetClients, ok := p.wsServer.Clients.GetAll(msg.TargetID); ok {
 				clients[msg.TargetID] = targetClients
+			} else if msg.TargetID != msg.SenderID {
+				p.offline.Push(context.Background(), msg, []int64{msg.TargetID})
 			}
 			if senderClients, ok := p.wsServer.Clients.GetAll(msg.SenderID); ok {
 				// sender==target 时会自动合并到同一个 key
@@ -62,28 +70,80 @@ func (p *Pusher) PushMessageToUser() error {
 					}
 				}
 			}
+		case constant.NotificationChatType:
+			// 通知只推给接收者
+			clients, ok := p.wsServer.Clients.GetAll(msg.TargetID)
+			if !ok {
+				p.offline.Push(context.Background(), msg, []int64{msg.TargetID})
+				return nil
+			}
+			for _, c := range clients {
+				if err := c.PushMessage(context.Background(), msg); err != nil {
+					log.Printf("push notification to user %d failed: %v", msg.TargetID, err)
+				}
+			}
 		case constant.GroupChatType:
-			log.Printf("[push] group message push not implemented")
-			memberInfos, _ := p.group.GetGroupMemberList(context.Background(), strconv.FormatInt(msg.TargetID, 10))
-			for _, member := range memberInfos {
-				memberID := member.UserID
-				clients, have := p.wsServer.Clients.GetAll(memberID)
-				if !have {
+			return p.pushGroupMessage(context.Background(), msg)
+		}
+		return nil
+	}
+	for {
+		err := group.Consume(context.Background(), []string{kafka.OnlinePushTopic}, onlinePushHandler{fn: pushToUsers})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// PushSignalToUser 消费在线信令并推送给目标用户的所有在线连接
+func (p *Pusher) PushSignalToUser() error {
+	group, err := kafka.NewConsumerGroup(kafka.SignalPushGroupID)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+	defer group.Close()
+	go func() {
+		for err := range group.Errors() {
+			log.Printf("ERROR: %v", err)
+		}
+	}()
+	pushToUsers := func(sig *notify.Signal) error {
+		userIDs, connID := sig.UserIDs, sig.ConnID
+		// 推给客户端时不需要携带接收者列表
+		sig.UserIDs, sig.ConnID = nil, ""
+		ctx := context.Background()
+		for _, userID := range userIDs {
+			clients, ok := p.wsServer.Clients.GetAll(userID)
+			if !ok {
+				continue
+			}
+			for _, c := range clients {
+				if connID != "" && c.ConnID != connID {
 					continue
 				}
-				for _, client := range clients {
-					if err := client.PushMessage(context.Background(), msg); err != nil {
-						log.Printf("push message to user %d failed: %v", memberID, err)
-					}
+				if err := p.pushSignal(ctx, c, sig); err != nil {
+					log.Printf("push signal to user %d failed: %v", userID, err)
 				}
 			}
 		}
 		return nil
 	}
 	for {
-		err := group.Consume(context.Background(), []string{kafka.OnlinePushTopic}, onlinePushHandler{fn: pushToUsers})
+		err := group.Consume(context.Background(), []string{kafka.SignalPushTopic}, signalPushHandler{fn: pushToUsers})
 		if err != nil {
 			panic(err)
 		}
 	}
 }
+
+func (p *Pusher) pushSignal(ctx context.Context, c *im.Client, sig *notify.Signal) error {
+	if sig.Type == constant.MsgTypeSendResult {
+		var result service.SendMessageResult
+		if err := json.Unmarshal(sig.Data, &result); err != nil {
+			return err
+		}
+		return c.PushSendResult(ctx, result.MsgIncr, result.ErrCode, result.ErrMsg, &result)
+	}
+	return c.PushSignal(ctx, sig)
+}
@@ -2,6 +2,7 @@ package pusher
 
 import (
 	"backend/internal/model"
+	"backend/internal/pkg/notify"
 	"encoding/json"
 	"log"
 
@@ -35,3 +36,25 @@ func (p onlinePushHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim
 	}
 	return nil
 }
+
+type signalPushHandler struct {
+	fn func(sig *notify.Signal) error
+}
+
+func (signalPushHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
+func (signalPushHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+func (p signalPushHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		var sig notify.Signal
+		if err := json.Unmarshal(msg.Value, &sig); err != nil {
+			log.Printf("push: invalid signal json topic=%s partition=%d offset=%d err=%v", msg.Topic, msg.Partition, msg.Offset, err)
+			sess.MarkMessage(msg, "")
+			continue
+		}
+		if err := p.fn(&sig); err != nil {
+			log.Printf("push: handle signal failed topic=%s offset=%d err=%v", msg.Topic, msg.Offset, err)
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
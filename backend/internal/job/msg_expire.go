@@ -0,0 +1,54 @@
+package job
+
+import (
+	"backend/internal/pkg/database"
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	msgExpireInterval  = 5 * time.Second
+	msgExpireBatchSize = 500
+)
+
+// MsgExpireJob 定期清理已过期的消息(定时销毁/阅后即焚)
+type MsgExpireJob struct {
+	messageService *service.MessageService
+}
+
+func NewMsgExpireJob() *MsgExpireJob {
+	return &MsgExpireJob{messageService: service.NewMessageService(database.GetDB())}
+}
+
+func (j *MsgExpireJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(msgExpireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runOnce(ctx)
+		}
+	}
+}
+
+func (j *MsgExpireJob) runOnce(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("msg expire job panic recovered: %v", r)
+		}
+	}()
+	for {
+		n, err := j.messageService.PurgeExpiredMessages(ctx, msgExpireBatchSize)
+		if err != nil {
+			log.Printf("msg expire job purge error: %v", err)
+			return
+		}
+		if n < msgExpireBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package job
+
+import (
+	"backend/internal/pkg/database"
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	msgRetentionInterval  = time.Hour
+	msgRetentionBatchSize = 200
+)
+
+// MsgRetentionJob 定期按保留策略截断历史消息
+type MsgRetentionJob struct {
+	messageService *service.MessageService
+}
+
+func NewMsgRetentionJob() *MsgRetentionJob {
+	return &MsgRetentionJob{messageService: service.NewMessageService(database.GetDB())}
+}
+
+func (j *MsgRetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(msgRetentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runOnce(ctx)
+		}
+	}
+}
+
+func (j *MsgRetentionJob) runOnce(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("msg retention job panic recovered: %v", r)
+		}
+	}()
+	var cursor string
+	for {
+		next, err := j.messageService.ApplyMsgRetention(ctx, cursor, msgRetentionBatchSize)
+		if err != nil {
+			log.Printf("msg retention job error: %v", err)
+			return
+		}
+		if next == "" {
+			return
+		}
+		cursor = next
+	}
+}
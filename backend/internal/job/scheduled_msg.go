@@ -0,0 +1,71 @@
+package job
+
+import (
+	"backend/internal/pkg/database"
+	"backend/internal/pkg/kafka"
+	"backend/internal/service"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	scheduledMsgInterval  = time.Second
+	scheduledMsgBatchSize = 100
+)
+
+// ScheduledMsgJob 轮询到期的定时消息并投递到 coming_message_topic。
+// 状态保存在数据库中，重启后继续处理；可以多实例同时运行。
+type ScheduledMsgJob struct {
+	messageService *service.MessageService
+	producer       *service.MsgProducer
+	instanceID     string
+}
+
+func NewScheduledMsgJob() (*ScheduledMsgJob, error) {
+	p, err := kafka.NewSyncProducer()
+	if err != nil {
+		return nil, err
+	}
+	messageService := service.NewMessageService(database.GetDB())
+	hostname, _ := os.Hostname()
+	return &ScheduledMsgJob{
+		messageService: messageService,
+		producer:       service.NewMsgProducer(messageService, p),
+		instanceID:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}, nil
+}
+
+func (j *ScheduledMsgJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduledMsgInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runOnce(ctx)
+		}
+	}
+}
+
+func (j *ScheduledMsgJob) runOnce(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scheduled msg job panic recovered: %v", r)
+		}
+	}()
+	for {
+		n, err := j.messageService.DispatchDueScheduledMessages(ctx, j.producer, j.instanceID, scheduledMsgBatchSize)
+		if err != nil {
+			log.Printf("scheduled msg job dispatch error: %v", err)
+			return
+		}
+		// 一批没处理完说明积压，继续处理
+		if n < scheduledMsgBatchSize {
+			return
+		}
+	}
+}
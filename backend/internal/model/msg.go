@@ -69,8 +69,8 @@ type Message struct {
 	ID int64 `gorm:"column:id;primaryKey;autoIncrement:false" json:"id,string"`
 
 	// 2. 归属与排序
-	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index:idx_conv_seq,priority:1;not null" json:"conversation_id"`
-	Seq            int64  `gorm:"column:seq;index:idx_conv_seq,priority:2;not null" json:"seq"` // 联合唯一索引的核心，群内递增
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index:idx_conv_seq,priority:1;index:idx_conv_root,priority:1;not null" json:"conversation_id"`
+	Seq            int64  `gorm:"column:seq;index:idx_conv_seq,priority:2;index:idx_conv_root,priority:3;not null" json:"seq"` // 联合唯一索引的核心，群内递增
 
 	// 3. 消息本体
 	SenderID    int64  `gorm:"column:sender_id;not null" json:"sender_id,string"`
@@ -93,6 +93,25 @@ type Message struct {
 	ConvType int32 `gorm:"column:conv_type;not null" json:"conv_type"`
 	TargetID int64 `gorm:"column:target_id;not null" json:"target_id,string"`
 
+	// 7. @信息，明细见 MsgAt
+	AtUserIDs []int64 `gorm:"column:at_user_ids;serializer:json;type:varchar(1024)" json:"at_user_ids,omitempty"`
+	IsAtAll   bool    `gorm:"column:is_at_all;default:false" json:"is_at_all,omitempty"`
+
+	// 8. 回复话题：RootMsgID 为话题根消息ID，RefMsg 为被引用消息的快照，客户端无需再拉取原消息
+	RootMsgID int64     `gorm:"column:root_msg_id;index:idx_conv_root,priority:2" json:"root_msg_id,string,omitempty"`
+	RefMsg    *QuoteMsg `gorm:"column:ref_msg;serializer:json;type:varchar(1024)" json:"ref_msg,omitempty"`
+
+	// 9. 阅后即焚/定时销毁：TTL 为存活秒数；普通消息发送时即计算 ExpireAt，阅后即焚的消息在对方已读后才开始计时
+	TTL           int64 `gorm:"column:ttl;default:0" json:"ttl,omitempty"`
+	BurnAfterRead bool  `gorm:"column:burn_after_read;default:false" json:"burn_after_read,omitempty"`
+	ExpireAt      int64 `gorm:"column:expire_at;default:0;index" json:"expire_at,omitempty"` // 过期时间(ms)，0 表示不过期
+
+	// 群事件的指定接收者(含刚被移出的成员)，为空时投递给全部群成员。只用于分发，不落库
+	RecvIDs []int64 `gorm:"-" json:"recv_ids,omitempty"`
+
+	// 表情回应，不落在消息表，拉取消息时由 MsgReactionCount 填充
+	Reactions []*MsgReactionSummary `gorm:"-" json:"reactions,omitempty"`
+
 	// 分库分表策略：通常按 ConversationID 取模分表
 }
 
@@ -100,6 +119,17 @@ func (Message) TableName() string {
 	return "messages"
 }
 
+// QuoteMsg 被引用消息的快照
+type QuoteMsg struct {
+	MsgID    int64  `json:"msg_id,string"`
+	Seq      int64  `json:"seq"`
+	SenderID int64  `json:"sender_id,string"`
+	MsgType  int32  `json:"msg_type"`
+	Snapshot string `json:"snapshot"` // 摘要："[图片]", "你好..."
+	SendTime int64  `json:"send_time"`
+	Revoked  bool   `json:"revoked,omitempty"`
+}
+
 type SeqUser struct {
 	UserID         int64  `gorm:"column:user_id;primaryKey" json:"user_id,string"`
 	ConversationID string `gorm:"column:conversation_id;type:varchar(64);primaryKey" json:"conversation_id"`
@@ -121,8 +151,8 @@ type UserTimeline struct {
 
 	// 2. 来源定位
 	ConversationID string `gorm:"column:conversation_id;type:varchar(64);not null"`
-	MsgID          int64  `gorm:"column:msg_id;not null"` // 关联 messages.id
-	RefMsgSeq      int64  `gorm:"column:ref_msg_seq"`     // 冗余一份 GroupSeq，方便客户端校验补洞
+	MsgID          int64  `gorm:"column:msg_id;not null;index"` // 关联 messages.id，清理消息时同步删除
+	RefMsgSeq      int64  `gorm:"column:ref_msg_seq"`           // 冗余一份 GroupSeq，方便客户端校验补洞
 
 	// 3. 列表页快照 (List View)
 	MsgType  int32  `gorm:"column:msg_type;not null"`
@@ -139,6 +169,16 @@ func (UserTimeline) TableName() string {
 	return "user_timelines"
 }
 
+// SeqUserTimeline 用户时间线的 seq 分配器，和会话 seq 分开存放
+type SeqUserTimeline struct {
+	OwnerID int64 `gorm:"column:owner_id;primaryKey" json:"owner_id,string"`
+	MaxSeq  int64 `gorm:"column:max_seq;not null" json:"max_seq"`
+}
+
+func (SeqUserTimeline) TableName() string {
+	return "seq_user_timelines"
+}
+
 // 1对1
 type MsgRevoke struct {
 	ID       uint   `gorm:"primaryKey;autoIncrement;column:id"`
@@ -154,15 +194,154 @@ func (MsgRevoke) TableName() string {
 }
 
 // 1对多
-// type MsgAt struct {
-// 	ID       uint   `gorm:"primaryKey;autoIncrement;column:id"`
-// 	MsgID    string `gorm:"column:msg_id;type:varchar(128);index"`
-// 	AtUserID string `gorm:"column:at_user_id;type:varchar(64);index"`
-// }
+type MsgAt struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement;column:id"`
+	MsgID          int64  `gorm:"column:msg_id;index"`
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index:idx_at_conv_user,priority:1;not null"`
+	AtUserID       int64  `gorm:"column:at_user_id;index:idx_at_conv_user,priority:2"` // 0 表示@所有人
+	Seq            int64  `gorm:"column:seq;not null"`
+	SenderID       int64  `gorm:"column:sender_id"`
+}
 
-// func (MsgAt) TableName() string {
-// 	return "msg_ats"
-// }
+func (MsgAt) TableName() string {
+	return "msg_ats"
+}
+
+// 表情回应明细，每个用户对同一消息的同一表情只有一条
+type MsgReaction struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement;column:id"`
+	MsgID          int64  `gorm:"column:msg_id;uniqueIndex:uk_reaction_msg_user_emoji,priority:1;not null" json:"msg_id,string"`
+	UserID         int64  `gorm:"column:user_id;uniqueIndex:uk_reaction_msg_user_emoji,priority:2;not null" json:"user_id,string"`
+	Emoji          string `gorm:"column:emoji;type:varchar(32);uniqueIndex:uk_reaction_msg_user_emoji,priority:3;not null" json:"emoji"`
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);not null" json:"conversation_id"`
+	CreateTime     int64  `gorm:"column:create_time;autoCreateTime:milli" json:"create_time"`
+}
+
+func (MsgReaction) TableName() string {
+	return "msg_reactions"
+}
+
+// 表情回应聚合计数
+type MsgReactionCount struct {
+	MsgID          int64  `gorm:"column:msg_id;primaryKey;autoIncrement:false" json:"msg_id,string"`
+	Emoji          string `gorm:"column:emoji;type:varchar(32);primaryKey" json:"emoji"`
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index;not null" json:"conversation_id"`
+	Count          int64  `gorm:"column:count;not null;default:0" json:"count"`
+}
+
+func (MsgReactionCount) TableName() string {
+	return "msg_reaction_counts"
+}
+
+// 会话置顶消息
+type MsgPin struct {
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);primaryKey" json:"conversation_id"`
+	MsgID          int64  `gorm:"column:msg_id;primaryKey;autoIncrement:false;index" json:"msg_id,string"`
+	Seq            int64  `gorm:"column:seq;not null" json:"seq"`
+	PinnedBy       int64  `gorm:"column:pinned_by;not null" json:"pinned_by,string"`
+	CreateTime     int64  `gorm:"column:create_time;autoCreateTime:milli" json:"create_time"`
+}
+
+func (MsgPin) TableName() string {
+	return "msg_pins"
+}
+
+// MsgReactionSummary 随消息返回的表情回应
+type MsgReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"` // 当前用户是否回应过
+}
+
+// 会话级设置，对会话所有成员生效
+type ConversationSetting struct {
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);primaryKey" json:"conversation_id"`
+	MsgTTL         int64  `gorm:"column:msg_ttl;default:0" json:"msg_ttl"`               // 新消息默认存活秒数，0 表示不过期
+	RetentionDays  int    `gorm:"column:retention_days;default:0" json:"retention_days"` // 历史消息保留天数，0 表示使用全局策略，仅群聊可设置
+	UpdatedBy      int64  `gorm:"column:updated_by" json:"updated_by,string"`
+	UpdateTime     int64  `gorm:"column:update_time;autoUpdateTime:milli" json:"update_time"`
+}
+
+func (ConversationSetting) TableName() string {
+	return "conversation_settings"
+}
+
+// 按保留策略归档的历史消息
+type MessageArchive struct {
+	ID             int64  `gorm:"column:id;primaryKey;autoIncrement:false" json:"id,string"`
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index:idx_archive_conv_seq,priority:1;not null" json:"conversation_id"`
+	Seq            int64  `gorm:"column:seq;index:idx_archive_conv_seq,priority:2;not null" json:"seq"`
+	SendTime       int64  `gorm:"column:send_time" json:"send_time"`
+	Data           string `gorm:"column:data;type:longtext" json:"data"` // 完整消息(JSON)
+	ArchiveTime    int64  `gorm:"column:archive_time;autoCreateTime:milli" json:"archive_time"`
+}
+
+func (MessageArchive) TableName() string {
+	return "message_archives"
+}
+
+// 内容审核记录，拦截、替换和标记的消息都会记录，供人工复核
+type MsgModerationRecord struct {
+	ID             int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id,string"`
+	ServerMsgID    int64  `gorm:"column:server_msg_id;index" json:"server_msg_id,string"` // 被拦截的消息为 0
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);not null" json:"conversation_id"`
+	SenderID       int64  `gorm:"column:sender_id;index;not null" json:"sender_id,string"`
+	MsgType        int32  `gorm:"column:msg_type;not null" json:"msg_type"`
+	Action         int32  `gorm:"column:action;not null" json:"action"` // 1=标记, 2=替换, 3=拦截
+	Source         string `gorm:"column:source;type:varchar(32)" json:"source"`
+	Words          string `gorm:"column:words;type:varchar(512)" json:"words"`
+	Content        string `gorm:"column:content;type:longtext" json:"content"` // 审核前的原始内容
+	CreateTime     int64  `gorm:"column:create_time;autoCreateTime:milli;index" json:"create_time"`
+}
+
+func (MsgModerationRecord) TableName() string {
+	return "msg_moderation_records"
+}
+
+// 定时消息，到期后由定时任务投递
+type ScheduledMessage struct {
+	ID        int64   `gorm:"column:id;primaryKey;autoIncrement:false" json:"id,string"`
+	SenderID  int64   `gorm:"column:sender_id;index;not null" json:"sender_id,string"`
+	ConvType  int32   `gorm:"column:conv_type;not null" json:"conv_type"`
+	TargetID  int64   `gorm:"column:target_id;not null" json:"target_id,string"`
+	MsgType   int32   `gorm:"column:msg_type;not null" json:"msg_type"`
+	Content   string  `gorm:"column:content;type:longtext" json:"content"`
+	AtUserIDs []int64 `gorm:"column:at_user_ids;serializer:json;type:varchar(1024)" json:"at_user_ids,omitempty"`
+	IsAtAll   bool    `gorm:"column:is_at_all;default:false" json:"is_at_all,omitempty"`
+	RefMsgID  int64   `gorm:"column:ref_msg_id" json:"ref_msg_id,string"`
+
+	SendAt int64 `gorm:"column:send_at;index:idx_sched_status_send_at,priority:2;not null" json:"send_at"` // 计划发送时间(ms)
+	// 0=待发送, 1=发送中, 2=已发送, 3=已取消, 4=发送失败
+	Status      int32  `gorm:"column:status;default:0;index:idx_sched_status_send_at,priority:1" json:"status"`
+	ClaimedBy   string `gorm:"column:claimed_by;type:varchar(128)" json:"-"`
+	ClaimedAt   int64  `gorm:"column:claimed_at" json:"-"`
+	RetryCount  int32  `gorm:"column:retry_count;default:0" json:"-"`
+	ServerMsgID int64  `gorm:"column:server_msg_id" json:"server_msg_id,string"`
+	ErrMsg      string `gorm:"column:err_msg;type:varchar(255)" json:"err_msg,omitempty"`
+
+	CreateTime int64 `gorm:"column:create_time;autoCreateTime:milli" json:"create_time"`
+	UpdateTime int64 `gorm:"column:update_time;autoUpdateTime:milli" json:"update_time"`
+}
+
+func (ScheduledMessage) TableName() string {
+	return "scheduled_messages"
+}
+
+// 消息全文检索倒排索引，每个词条一行
+type MsgSearchIndex struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement;column:id"`
+	Token          string `gorm:"column:token;type:varchar(64);index:idx_search_token_conv,priority:1;not null"`
+	ConversationID string `gorm:"column:conversation_id;type:varchar(64);index:idx_search_token_conv,priority:2;not null"`
+	MsgID          int64  `gorm:"column:msg_id;index;not null"`
+	Seq            int64  `gorm:"column:seq;not null"`
+	SenderID       int64  `gorm:"column:sender_id"`
+	MsgType        int32  `gorm:"column:msg_type"`
+	SendTime       int64  `gorm:"column:send_time;index:idx_search_token_conv,priority:3"`
+}
+
+func (MsgSearchIndex) TableName() string {
+	return "msg_search_indices"
+}
 
 // 1对多
 // type MsgDelete struct {
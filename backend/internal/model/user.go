@@ -78,3 +78,14 @@ func (u *User) GetEx() (*UserEx, error) {
 	err := json.Unmarshal([]byte(u.Ex), &exObj)
 	return &exObj, err
 }
+
+// DeviceToken 设备的离线推送 token，每个设备只保留最新上报的一个
+type DeviceToken struct {
+	UserID     int64  `gorm:"column:user_id;primaryKey" json:"user_id,string"`
+	DeviceID   string `gorm:"column:device_id;type:varchar(64);primaryKey" json:"device_id"`
+	Provider   string `gorm:"column:provider;type:varchar(32);not null;comment:推送通道 apns/fcm/厂商名" json:"provider"`
+	Token      string `gorm:"column:token;type:varchar(255);not null;index" json:"token"`
+	UpdateTime int64  `gorm:"column:update_time;autoUpdateTime:milli" json:"update_time"`
+}
+
+func (DeviceToken) TableName() string { return "device_tokens" }
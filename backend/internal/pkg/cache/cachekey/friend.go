@@ -0,0 +1,10 @@
+package cachekey
+
+const (
+	UserRelationKey = "USER_RELATION:"
+)
+
+// GetUserRelationKey ownerUserID 对 userID 的好友/黑名单关系
+func GetUserRelationKey(ownerUserID, userID string) string {
+	return UserRelationKey + ownerUserID + ":" + userID
+}
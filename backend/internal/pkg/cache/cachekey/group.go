@@ -0,0 +1,22 @@
+package cachekey
+
+const (
+	GroupMemberIDsKey  = "GROUP_MEMBER_IDS:"
+	GroupMemberMuteKey = "GROUP_MEMBER_MUTE:"
+	GroupStatusKey     = "GROUP_STATUS:"
+)
+
+// GetGroupMemberIDsKey 群全部成员ID，成员变化时删除
+func GetGroupMemberIDsKey(groupID string) string {
+	return GroupMemberIDsKey + groupID
+}
+
+// GetGroupMemberMuteKey 成员角色和禁言截止时间，禁言、角色或成员变化时删除
+func GetGroupMemberMuteKey(groupID, userID string) string {
+	return GroupMemberMuteKey + groupID + ":" + userID
+}
+
+// GetGroupStatusKey 群状态(全员禁言、解散)，状态变化时删除
+func GetGroupStatusKey(groupID string) string {
+	return GroupStatusKey + groupID
+}
@@ -7,6 +7,7 @@ import (
 const (
 	sendMsgFailedFlag = "SEND_MSG_FAILED_FLAG:"
 	messageCache      = "MSG_CACHE:"
+	msgDedup          = "MSG_DEDUP:"
 )
 
 func GetMsgCacheKey(conversationID string, seq int64) string {
@@ -16,3 +17,7 @@ func GetMsgCacheKey(conversationID string, seq int64) string {
 func GetSendMsgKey(id string) string {
 	return sendMsgFailedFlag + id
 }
+
+func GetMsgDedupKey(senderID string, clientMsgID string) string {
+	return msgDedup + senderID + ":" + clientMsgID
+}
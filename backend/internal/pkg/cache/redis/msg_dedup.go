@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MsgDedupRecord 发送者 + ClientMsgID 对应的服务端消息，Seq 为 0 表示消息还在投递中
+type MsgDedupRecord struct {
+	MsgID          int64  `json:"msg_id,string"`
+	Seq            int64  `json:"seq"`
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// MsgDedupCacheRedis 基于 ClientMsgID 的发送去重，记录在窗口期内有效
+type MsgDedupCacheRedis struct {
+	client *redis.Client
+	window time.Duration
+}
+
+func NewMsgDedupCacheRedis(client *redis.Client, window time.Duration) *MsgDedupCacheRedis {
+	return &MsgDedupCacheRedis{
+		client: client,
+		window: window,
+	}
+}
+
+func (m *MsgDedupCacheRedis) key(senderID int64, clientMsgID string) string {
+	return cachekey.GetMsgDedupKey(strconv.FormatInt(senderID, 10), clientMsgID)
+}
+
+// Reserve 以 rec 登记 ClientMsgID。已被登记过时返回已有记录且 reserved=false。
+func (m *MsgDedupCacheRedis) Reserve(ctx context.Context, senderID int64, clientMsgID string, rec MsgDedupRecord) (MsgDedupRecord, bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return MsgDedupRecord{}, false, err
+	}
+	key := m.key(senderID, clientMsgID)
+	for i := 0; i < 3; i++ {
+		ok, err := m.client.SetNX(ctx, key, data, m.window).Result()
+		if err != nil {
+			return MsgDedupRecord{}, false, err
+		}
+		if ok {
+			return rec, true, nil
+		}
+		val, err := m.client.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// 恰好过期，重新登记
+			continue
+		}
+		if err != nil {
+			return MsgDedupRecord{}, false, err
+		}
+		var exist MsgDedupRecord
+		if err := json.Unmarshal(val, &exist); err != nil {
+			return MsgDedupRecord{}, false, err
+		}
+		return exist, false, nil
+	}
+	return MsgDedupRecord{}, false, errors.New("msg dedup: reserve retry exceeded")
+}
+
+// Complete 消息分配 seq 后回填记录，后续重试可以直接拿到 seq
+func (m *MsgDedupCacheRedis) Complete(ctx context.Context, msgs []*model.Message) error {
+	pipe := m.client.Pipeline()
+	for _, msg := range msgs {
+		if msg.ClientMsgID == "" {
+			continue
+		}
+		data, err := json.Marshal(MsgDedupRecord{
+			MsgID:          msg.ID,
+			Seq:            msg.Seq,
+			ConversationID: msg.ConversationID,
+		})
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, m.key(msg.SenderID, msg.ClientMsgID), data, m.window)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Release 投递失败时删除登记，允许客户端重试
+func (m *MsgDedupCacheRedis) Release(ctx context.Context, senderID int64, clientMsgID string) error {
+	return m.client.Del(ctx, m.key(senderID, clientMsgID)).Err()
+}
@@ -48,6 +48,21 @@ func (s *SeqConversationCacheRedis) GetMinSeq(ctx context.Context, conversationI
 	return minSeq, err
 }
 
+// SetMinSeq 推进会话的 MinSeq，只前进不后退
+func (s *SeqConversationCacheRedis) SetMinSeq(ctx context.Context, conversationID string, minSeq int64) error {
+	if err := s.db.WithContext(ctx).Model(&model.SeqConversation{}).
+		Where("id = ? AND min_seq < ?", conversationID, minSeq).
+		Update("min_seq", minSeq).Error; err != nil {
+		return err
+	}
+	return s.client.Del(ctx, cachekey.GetSeqConvMinSeqKey(conversationID)).Err()
+}
+
+// DelMinSeqCache 在事务中直接修改 min_seq 后调用
+func (s *SeqConversationCacheRedis) DelMinSeqCache(ctx context.Context, conversationID string) error {
+	return s.client.Del(ctx, cachekey.GetSeqConvMinSeqKey(conversationID)).Err()
+}
+
 type SeqTime struct {
 	Seq  int64
 	Time int64
@@ -8,19 +8,54 @@ const (
 )
 
 const (
+	// 旧版客户端发送的纯文本消息，服务端转换为 MsgTypeText
+	MsgTypeLegacyText = 1
+
 	// --- 基础内容 (确实是 ContentType) ---
-	MsgTypeText  = 101
-	MsgTypeImage = 102
-	MsgTypeVideo = 103
+	MsgTypeText     = 101
+	MsgTypeImage    = 102
+	MsgTypeVideo    = 103
+	MsgTypeFile     = 104
+	MsgTypeAudio    = 105
+	MsgTypeLocation = 106
+	MsgTypeCard     = 107 // 名片
+	MsgTypeMerge    = 108 // 合并转发的聊天记录
 
 	// --- 业务信令 (这叫 ContentType 就不合适了) ---
 	MsgTypeRevoke     = 201 // 撤回
 	MsgTypeReadReport = 202 // 已读回执
 	MsgTypeTyping     = 203 // "正在输入中..."
+	MsgTypeReaction   = 204 // 表情回应变化
+	MsgTypeSendResult = 205 // 消息发送结果，只推给发送消息的连接
+	MsgTypeMsgExpired = 206 // 消息过期被销毁
+	MsgTypePinUpdate  = 207 // 会话置顶消息变化
+	MsgTypeNewSeq     = 208 // 大群有新消息，只通知 seq，客户端按 seq 拉取
+
+	// --- 系统通知，发往用户的 NotificationChatType 会话 ---
+	MsgTypeFriendApplyNotification = 401 // 好友申请及处理结果
+	MsgTypeGroupApplyNotification  = 402 // 入群申请及处理结果
+	MsgTypeAccountNotification     = 403 // 账号事件(登录、资料变更等)
 
 	// --- 群组事件 (这也是业务逻辑) ---
-	MsgTypeMemberJoin = 301 // "张三加入群聊"
-	MsgTypeGroupMute  = 302 // "群主开启了全员禁言"
+	MsgTypeMemberJoin      = 301 // "张三加入群聊"
+	MsgTypeGroupMute       = 302 // "群主开启了全员禁言"
+	MsgTypeMemberQuit      = 303 // 成员退出群聊
+	MsgTypeMemberKicked    = 304 // 成员被移出群聊
+	MsgTypeGroupInfoSet    = 305 // 群资料(名称、公告、头像等)变更
+	MsgTypeGroupDismissed  = 306 // 群聊解散
+	MsgTypeMemberInfoSet   = 307 // 群成员资料/角色变更
+	MsgTypeGroupCreated    = 308 // 群聊创建
+	MsgTypeMsgPinned       = 309 // 群消息被置顶
+	MsgTypeMsgUnpinned     = 310 // 群消息取消置顶
+	MsgTypeGroupCancelMute = 311 // 关闭全员禁言
+	MsgTypeMemberMuted     = 312 // 成员被禁言
+	MsgTypeMemberUnmuted   = 313 // 成员被解除禁言
+)
+
+const (
+	// AppManagerLevel.
+	AppOrdinaryUsers = 1
+	AppAdmin         = 2
 )
 
 const (
@@ -5,4 +5,6 @@ const (
 	OnlinePushTopic      = "online_push_topic"
 	ComingMessageTopic   = "coming_message_topic"
 	ComingMessageGroupID = "coming_message_group"
+	SignalPushTopic      = "signal_push_topic"
+	SignalPushGroupID    = "signal_push_group"
 )
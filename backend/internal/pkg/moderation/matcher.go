@@ -0,0 +1,106 @@
+package moderation
+
+import "unicode"
+
+// Match 一次命中，Start/End 为 rune 下标，左闭右开
+type Match struct {
+	Start int
+	End   int
+	Word  string
+}
+
+type acNode struct {
+	next   map[rune]int
+	fail   int
+	output []int // 以该节点结尾的词在 words 中的下标
+}
+
+// Matcher Aho-Corasick 多模式匹配器，构建后只读，可并发使用。匹配不区分大小写
+type Matcher struct {
+	nodes []acNode
+	words [][]rune
+}
+
+func NewMatcher(words []string) *Matcher {
+	m := &Matcher{nodes: []acNode{{next: make(map[rune]int)}}}
+	for _, word := range words {
+		runes := toLowerRunes(word)
+		if len(runes) == 0 {
+			continue
+		}
+		cur := 0
+		for _, r := range runes {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				m.nodes = append(m.nodes, acNode{next: make(map[rune]int)})
+				nxt = len(m.nodes) - 1
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].output = append(m.nodes[cur].output, len(m.words))
+		m.words = append(m.words, runes)
+	}
+	m.buildFail()
+	return m
+}
+
+// buildFail 按 BFS 构建失配指针，并把失配链上的输出合并到当前节点
+func (m *Matcher) buildFail() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f != 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			m.nodes[child].output = append(m.nodes[child].output, m.nodes[m.nodes[child].fail].output...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAll 返回 text 中所有命中，包括相互重叠的
+func (m *Matcher) FindAll(text string) []Match {
+	if len(m.words) == 0 {
+		return nil
+	}
+	var matches []Match
+	cur := 0
+	for i, r := range toLowerRunes(text) {
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if nxt, ok := m.nodes[cur].next[r]; ok {
+			cur = nxt
+		}
+		for _, idx := range m.nodes[cur].output {
+			word := m.words[idx]
+			matches = append(matches, Match{Start: i + 1 - len(word), End: i + 1, Word: string(word)})
+		}
+	}
+	return matches
+}
+
+// toLowerRunes 逐个 rune 转小写，保持 rune 数量不变，命中下标可直接用于原文
+func toLowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// Action 审核结论，数值越大越严格
+type Action int32
+
+const (
+	ActionPass  Action = 0
+	ActionFlag  Action = 1 // 放行，记录待人工复核
+	ActionMask  Action = 2 // 命中部分替换为 * 后放行
+	ActionBlock Action = 3 // 拒绝发送
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionFlag:
+		return "flag"
+	case ActionMask:
+		return "mask"
+	case ActionBlock:
+		return "block"
+	}
+	return "pass"
+}
+
+// ParseAction 解析配置中的动作名，无法识别时返回 def
+func ParseAction(s string, def Action) Action {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "flag":
+		return ActionFlag
+	case "mask":
+		return ActionMask
+	case "block":
+		return ActionBlock
+	}
+	return def
+}
+
+type Result struct {
+	Action  Action
+	Content string   // 审核后的内容，Mask 时为替换后的内容
+	Words   []string // 命中的敏感词或分类标签
+	Source  string   // 给出最终结论的审核器
+}
+
+// Moderator 消息内容审核器。外部分类服务实现该接口后加入 Chain 即可
+type Moderator interface {
+	Name() string
+	Moderate(ctx context.Context, msgType int32, content string) (*Result, error)
+}
+
+// Chain 依次执行多个审核器，后一个审核器看到的是前一个处理后的内容。
+// 任一审核器拒绝即停止；单个审核器出错时跳过，不影响消息发送。
+type Chain []Moderator
+
+func (c Chain) Name() string { return "chain" }
+
+func (c Chain) Moderate(ctx context.Context, msgType int32, content string) (*Result, error) {
+	final := &Result{Action: ActionPass, Content: content}
+	for _, m := range c {
+		res, err := m.Moderate(ctx, msgType, final.Content)
+		if err != nil {
+			log.Printf("moderation: %s error: %v", m.Name(), err)
+			continue
+		}
+		if res == nil || res.Action == ActionPass {
+			continue
+		}
+		final.Words = append(final.Words, res.Words...)
+		if res.Action > final.Action {
+			final.Action, final.Source = res.Action, m.Name()
+		}
+		switch res.Action {
+		case ActionBlock:
+			return final, nil
+		case ActionMask:
+			final.Content = res.Content
+		}
+	}
+	return final, nil
+}
@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatcherFindAll(t *testing.T) {
+	m := NewMatcher([]string{"he", "she", "his", "hers", "坏人"})
+	got := m.FindAll("uShers 是坏人")
+	want := []Match{
+		{Start: 1, End: 4, Word: "she"},
+		{Start: 2, End: 4, Word: "he"},
+		{Start: 2, End: 6, Word: "hers"},
+		{Start: 8, End: 10, Word: "坏人"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FindAll = %+v, want %+v", got, want)
+	}
+	if got := NewMatcher(nil).FindAll("anything"); got != nil {
+		t.Fatalf("empty matcher = %+v", got)
+	}
+}
+
+func TestWordFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# comment\nbad\nspam|block\nreview|flag\nbad\"quote\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewWordFilter(path, ActionMask)
+	if err != nil {
+		t.Fatalf("NewWordFilter error: %v", err)
+	}
+	ctx := context.Background()
+
+	res, err := f.Moderate(ctx, 101, `{"content":"so BAD day","url":"bad"}`)
+	if err != nil {
+		t.Fatalf("Moderate error: %v", err)
+	}
+	if res.Action != ActionMask || res.Content != `{"content":"so *** day","url":"***"}` {
+		t.Fatalf("mask result = %+v", res)
+	}
+	// 字段名不检查
+	if res, _ := f.Moderate(ctx, 101, `{"bad":"ok"}`); res.Action != ActionPass {
+		t.Fatalf("key should not match: %+v", res)
+	}
+	if res, _ := f.Moderate(ctx, 101, `{"content":"bad spam"}`); res.Action != ActionBlock {
+		t.Fatalf("block result = %+v", res)
+	}
+	if res, _ := f.Moderate(ctx, 101, `{"content":"review me"}`); res.Action != ActionFlag || res.Content != `{"content":"review me"}` {
+		t.Fatalf("flag result = %+v", res)
+	}
+	// 大整数不丢精度
+	if res, _ := f.Moderate(ctx, 107, `{"user_id":1234567890123456789,"nickname":"bad"}`); res.Content != `{"nickname":"***","user_id":1234567890123456789}` {
+		t.Fatalf("number result = %+v", res)
+	}
+
+	// 热加载
+	later := time.Now().Add(time.Second)
+	if err := os.WriteFile(path, []byte("good\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, later, later)
+	if err := f.Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if res, _ := f.Moderate(ctx, 101, `{"content":"bad good"}`); res.Content != `{"content":"bad ****"}` {
+		t.Fatalf("reloaded result = %+v", res)
+	}
+}
+
+type stubModerator struct {
+	res *Result
+	err error
+}
+
+func (s stubModerator) Name() string { return "stub" }
+
+func (s stubModerator) Moderate(ctx context.Context, msgType int32, content string) (*Result, error) {
+	return s.res, s.err
+}
+
+func TestChain(t *testing.T) {
+	f := &WordFilter{defaultAction: ActionMask}
+	f.SetWords(map[string]Action{"bad": ActionMask})
+	chain := Chain{
+		stubModerator{err: errors.New("timeout")},
+		f,
+		stubModerator{res: &Result{Action: ActionFlag, Words: []string{"ad"}}},
+	}
+	res, err := chain.Moderate(context.Background(), 101, `{"content":"bad"}`)
+	if err != nil {
+		t.Fatalf("Moderate error: %v", err)
+	}
+	if res.Action != ActionMask || res.Source != "word_filter" || res.Content != `{"content":"***"}` || len(res.Words) != 2 {
+		t.Fatalf("chain result = %+v", res)
+	}
+	chain = append(chain, stubModerator{res: &Result{Action: ActionBlock}})
+	if res, _ := chain.Moderate(context.Background(), 101, `{"content":"ok"}`); res.Action != ActionBlock {
+		t.Fatalf("chain block = %+v", res)
+	}
+}
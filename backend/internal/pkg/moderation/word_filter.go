@@ -0,0 +1,204 @@
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type wordSet struct {
+	matcher *Matcher
+	actions map[string]Action // 小写词 -> 动作
+}
+
+// WordFilter 基于敏感词表的审核器，词表文件修改后由 Watch 自动重新加载。
+//
+// 词表每行一个词，可用 "词|动作" 单独指定动作(block/mask/flag)，# 开头为注释。
+// 只检查消息 JSON 中的字符串值，不检查字段名，替换后仍是合法的 JSON。
+type WordFilter struct {
+	path          string
+	defaultAction Action
+
+	mu      sync.Mutex
+	modTime time.Time
+	words   atomic.Pointer[wordSet]
+}
+
+func NewWordFilter(path string, defaultAction Action) (*WordFilter, error) {
+	f := &WordFilter{path: path, defaultAction: defaultAction}
+	f.SetWords(nil)
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *WordFilter) Name() string { return "word_filter" }
+
+// SetWords 直接替换词表，动作为 ActionPass 的词使用默认动作
+func (f *WordFilter) SetWords(words map[string]Action) {
+	set := &wordSet{actions: make(map[string]Action, len(words))}
+	list := make([]string, 0, len(words))
+	for word, action := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		if action == ActionPass {
+			action = f.defaultAction
+		}
+		if old, ok := set.actions[word]; !ok || action > old {
+			set.actions[word] = action
+		}
+		list = append(list, word)
+	}
+	set.matcher = NewMatcher(list)
+	f.words.Store(set)
+}
+
+// Reload 词表文件有变化时重新加载，加载失败保留原词表
+func (f *WordFilter) Reload() error {
+	if f.path == "" {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	words := parseWordList(data)
+	f.SetWords(words)
+	f.modTime = info.ModTime()
+	log.Printf("moderation: loaded %d words from %s", len(words), f.path)
+	return nil
+}
+
+// Watch 定期检查词表文件，ctx 取消后退出
+func (f *WordFilter) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Reload(); err != nil {
+				log.Printf("moderation: reload %s error: %v", f.path, err)
+			}
+		}
+	}
+}
+
+func parseWordList(data []byte) map[string]Action {
+	words := make(map[string]Action)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, action, _ := strings.Cut(line, "|")
+		word = strings.TrimSpace(word)
+		// 含引号、反斜杠的词替换后会破坏 JSON，忽略
+		if word == "" || strings.ContainsAny(word, "\"\\") {
+			continue
+		}
+		words[word] = ParseAction(action, ActionPass)
+	}
+	return words
+}
+
+func (f *WordFilter) Moderate(ctx context.Context, msgType int32, content string) (*Result, error) {
+	set := f.words.Load()
+	res := &Result{Action: ActionPass, Content: content, Source: f.Name()}
+	if len(set.matcher.words) == 0 {
+		return res, nil
+	}
+	check := func(s string) string {
+		matches := set.matcher.FindAll(s)
+		if len(matches) == 0 {
+			return s
+		}
+		var runes []rune
+		for _, m := range matches {
+			action := set.actions[m.Word]
+			res.Words = append(res.Words, m.Word)
+			if action > res.Action {
+				res.Action = action
+			}
+			if action == ActionMask {
+				if runes == nil {
+					runes = []rune(s)
+				}
+				for i := m.Start; i < m.End; i++ {
+					runes[i] = '*'
+				}
+			}
+		}
+		if runes == nil {
+			return s
+		}
+		return string(runes)
+	}
+
+	var value any
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		res.Content = check(content)
+		return finishResult(res, content), nil
+	}
+	masked := walkStrings(value, check)
+	if res.Action == ActionMask {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(masked); err != nil {
+			return nil, err
+		}
+		res.Content = strings.TrimSuffix(buf.String(), "\n")
+	}
+	return finishResult(res, content), nil
+}
+
+// finishResult 未得出 Mask 结论时保持原文，避免 flag 词被替换
+func finishResult(res *Result, content string) *Result {
+	if res.Action != ActionMask {
+		res.Content = content
+	}
+	return res
+}
+
+// walkStrings 对 JSON 中的字符串值逐个调用 fn，返回替换后的值
+func walkStrings(v any, fn func(string) string) any {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case map[string]any:
+		for k, item := range val {
+			val[k] = walkStrings(item, fn)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = walkStrings(item, fn)
+		}
+		return val
+	}
+	return v
+}
@@ -0,0 +1,204 @@
+package msgcontent
+
+import (
+	"backend/internal/pkg/constant"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// 文本消息最大字符数
+	maxTextLen = 5000
+	// 合并转发最多包含的消息数
+	MaxMergeMsgNum = 100
+)
+
+func init() {
+	RegisterElem[TextElem](constant.MsgTypeText)
+	RegisterElem[ImageElem](constant.MsgTypeImage)
+	RegisterElem[VideoElem](constant.MsgTypeVideo)
+	RegisterElem[FileElem](constant.MsgTypeFile)
+	RegisterElem[AudioElem](constant.MsgTypeAudio)
+	RegisterElem[LocationElem](constant.MsgTypeLocation)
+	RegisterElem[CardElem](constant.MsgTypeCard)
+	RegisterElem[MergeElem](constant.MsgTypeMerge)
+}
+
+type TextElem struct {
+	Content string `json:"content"`
+}
+
+func (e *TextElem) Validate() error {
+	if strings.TrimSpace(e.Content) == "" {
+		return errors.New("text content is empty")
+	}
+	if utf8.RuneCountInString(e.Content) > maxTextLen {
+		return ErrContentTooLong
+	}
+	return nil
+}
+
+func (e *TextElem) Snapshot() string { return e.Content }
+
+func (e *TextElem) SearchText() string { return e.Content }
+
+type ImageElem struct {
+	URL       string `json:"url"`
+	ThumbURL  string `json:"thumb_url"`
+	Width     int32  `json:"width"`
+	Height    int32  `json:"height"`
+	Size      int64  `json:"size"`
+	Extension string `json:"extension"`
+}
+
+func (e *ImageElem) Validate() error {
+	if e.URL == "" {
+		return errors.New("image url is empty")
+	}
+	if e.Width < 0 || e.Height < 0 || e.Size < 0 {
+		return errors.New("invalid image size")
+	}
+	return nil
+}
+
+func (e *ImageElem) Snapshot() string { return "[图片]" }
+
+type VideoElem struct {
+	URL      string `json:"url"`
+	CoverURL string `json:"cover_url"`
+	Duration int64  `json:"duration"` // 秒
+	Width    int32  `json:"width"`
+	Height   int32  `json:"height"`
+	Size     int64  `json:"size"`
+}
+
+func (e *VideoElem) Validate() error {
+	if e.URL == "" {
+		return errors.New("video url is empty")
+	}
+	if e.Duration < 0 || e.Size < 0 {
+		return errors.New("invalid video duration or size")
+	}
+	return nil
+}
+
+func (e *VideoElem) Snapshot() string { return "[视频]" }
+
+type FileElem struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func (e *FileElem) Validate() error {
+	if e.URL == "" || e.Name == "" {
+		return errors.New("file url or name is empty")
+	}
+	if e.Size < 0 {
+		return errors.New("invalid file size")
+	}
+	return nil
+}
+
+func (e *FileElem) Snapshot() string { return "[文件] " + e.Name }
+
+func (e *FileElem) SearchText() string { return e.Name }
+
+type AudioElem struct {
+	URL      string `json:"url"`
+	Duration int64  `json:"duration"` // 秒
+	Size     int64  `json:"size"`
+}
+
+func (e *AudioElem) Validate() error {
+	if e.URL == "" {
+		return errors.New("audio url is empty")
+	}
+	if e.Duration <= 0 || e.Size < 0 {
+		return errors.New("invalid audio duration or size")
+	}
+	return nil
+}
+
+func (e *AudioElem) Snapshot() string { return "[语音]" }
+
+type LocationElem struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+}
+
+func (e *LocationElem) Validate() error {
+	if e.Latitude < -90 || e.Latitude > 90 || e.Longitude < -180 || e.Longitude > 180 {
+		return errors.New("invalid latitude or longitude")
+	}
+	return nil
+}
+
+func (e *LocationElem) Snapshot() string {
+	if e.Name != "" {
+		return "[位置] " + e.Name
+	}
+	return "[位置]"
+}
+
+func (e *LocationElem) SearchText() string { return e.Name + " " + e.Address }
+
+// CardElem 个人名片
+type CardElem struct {
+	UserID   int64  `json:"user_id,string"`
+	Nickname string `json:"nickname"`
+	FaceURL  string `json:"face_url"`
+}
+
+func (e *CardElem) Validate() error {
+	if e.UserID == 0 {
+		return errors.New("card user id is empty")
+	}
+	return nil
+}
+
+func (e *CardElem) Snapshot() string { return "[名片] " + e.Nickname }
+
+func (e *CardElem) SearchText() string { return e.Nickname }
+
+// MergeElem 合并转发的聊天记录，内嵌原消息的副本，原消息被撤回或清理后仍可查看
+type MergeElem struct {
+	Title    string       `json:"title"`
+	Messages []*MergedMsg `json:"messages"`
+}
+
+type MergedMsg struct {
+	MsgID          int64  `json:"msg_id,string"`
+	ConversationID string `json:"conversation_id"`
+	SenderID       int64  `json:"sender_id,string"`
+	MsgType        int32  `json:"msg_type"`
+	Content        string `json:"content"`
+	SendTime       int64  `json:"send_time"`
+}
+
+func (e *MergeElem) Validate() error {
+	if len(e.Messages) == 0 {
+		return errors.New("merge messages is empty")
+	}
+	if len(e.Messages) > MaxMergeMsgNum {
+		return errors.New("too many merge messages")
+	}
+	for _, m := range e.Messages {
+		if m == nil || m.Content == "" {
+			return errors.New("merge message content is empty")
+		}
+	}
+	return nil
+}
+
+func (e *MergeElem) Snapshot() string {
+	if e.Title != "" {
+		return "[聊天记录] " + e.Title
+	}
+	return "[聊天记录]"
+}
+
+func (e *MergeElem) SearchText() string { return e.Title }
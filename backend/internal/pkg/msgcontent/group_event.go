@@ -0,0 +1,147 @@
+package msgcontent
+
+import (
+	"backend/internal/pkg/constant"
+	"errors"
+)
+
+func init() {
+	RegisterElem[GroupCreatedElem](constant.MsgTypeGroupCreated)
+	RegisterElem[MemberJoinElem](constant.MsgTypeMemberJoin)
+	RegisterElem[MemberQuitElem](constant.MsgTypeMemberQuit)
+	RegisterElem[MemberKickedElem](constant.MsgTypeMemberKicked)
+	RegisterElem[GroupInfoSetElem](constant.MsgTypeGroupInfoSet)
+	RegisterElem[GroupDismissedElem](constant.MsgTypeGroupDismissed)
+	RegisterElem[MemberInfoSetElem](constant.MsgTypeMemberInfoSet)
+	RegisterElem[MsgPinnedElem](constant.MsgTypeMsgPinned)
+	RegisterElem[MsgUnpinnedElem](constant.MsgTypeMsgUnpinned)
+	RegisterElem[GroupMutedElem](constant.MsgTypeGroupMute)
+	RegisterElem[GroupCancelMutedElem](constant.MsgTypeGroupCancelMute)
+	RegisterElem[MemberMutedElem](constant.MsgTypeMemberMuted)
+	RegisterElem[MemberUnmutedElem](constant.MsgTypeMemberUnmuted)
+}
+
+// GroupEventBase 群事件的公共字段，客户端收到后按 GroupID 刷新本地群资料/成员缓存
+type GroupEventBase struct {
+	GroupID        string `json:"group_id"`
+	OperatorUserID int64  `json:"operator_user_id,string"`
+}
+
+func (e *GroupEventBase) Validate() error {
+	if e.GroupID == "" {
+		return errors.New("group event missing group id")
+	}
+	return nil
+}
+
+type GroupCreatedElem struct {
+	GroupEventBase
+	GroupName string `json:"group_name"`
+}
+
+func (e *GroupCreatedElem) Snapshot() string { return "[群聊已创建]" }
+
+type MemberJoinElem struct {
+	GroupEventBase
+	UserIDs       []int64 `json:"user_ids"`
+	InviterUserID int64   `json:"inviter_user_id,string,omitempty"`
+}
+
+func (e *MemberJoinElem) Snapshot() string { return "[新成员加入群聊]" }
+
+type MemberQuitElem struct {
+	GroupEventBase
+	UserID int64 `json:"user_id,string"`
+}
+
+func (e *MemberQuitElem) Snapshot() string { return "[成员退出群聊]" }
+
+type MemberKickedElem struct {
+	GroupEventBase
+	UserIDs []int64 `json:"user_ids"`
+}
+
+func (e *MemberKickedElem) Snapshot() string { return "[成员被移出群聊]" }
+
+// GroupInfoSetElem 只包含本次变更的字段
+type GroupInfoSetElem struct {
+	GroupEventBase
+	GroupName         *string `json:"group_name,omitempty"`
+	AvatarURL         *string `json:"avatar_url,omitempty"`
+	Notification      *string `json:"notification,omitempty"`
+	Introduction      *string `json:"introduction,omitempty"`
+	NeedVerification  *int32  `json:"need_verification,omitempty"`
+	LookMemberInfo    *int32  `json:"look_member_info,omitempty"`
+	ApplyMemberFriend *int32  `json:"apply_member_friend,omitempty"`
+}
+
+func (e *GroupInfoSetElem) Snapshot() string {
+	if e.Notification != nil {
+		return "[群公告] " + *e.Notification
+	}
+	return "[群资料已修改]"
+}
+
+type GroupDismissedElem struct {
+	GroupEventBase
+}
+
+func (e *GroupDismissedElem) Snapshot() string { return "[群聊已解散]" }
+
+// MemberInfoSetElem 只包含本次变更的字段
+type MemberInfoSetElem struct {
+	GroupEventBase
+	UserID    int64   `json:"user_id,string"`
+	Nickname  *string `json:"nickname,omitempty"`
+	AvatarURL *string `json:"avatar_url,omitempty"`
+	RoleLevel *int32  `json:"role_level,omitempty"`
+}
+
+func (e *MemberInfoSetElem) Snapshot() string { return "[群成员资料已修改]" }
+
+// MsgPinnedElem 被置顶的消息，MsgAbstract 为其摘要
+type MsgPinnedElem struct {
+	GroupEventBase
+	MsgID       int64  `json:"msg_id,string"`
+	Seq         int64  `json:"seq"`
+	MsgAbstract string `json:"msg_abstract,omitempty"`
+}
+
+func (e *MsgPinnedElem) Snapshot() string { return "[置顶了一条消息]" }
+
+type MsgUnpinnedElem struct {
+	GroupEventBase
+	MsgID int64 `json:"msg_id,string"`
+	Seq   int64 `json:"seq"`
+}
+
+func (e *MsgUnpinnedElem) Snapshot() string { return "[取消置顶了一条消息]" }
+
+// GroupMutedElem 开启全员禁言，群主和管理员不受限制
+type GroupMutedElem struct {
+	GroupEventBase
+}
+
+func (e *GroupMutedElem) Snapshot() string { return "[已开启全员禁言]" }
+
+type GroupCancelMutedElem struct {
+	GroupEventBase
+}
+
+func (e *GroupCancelMutedElem) Snapshot() string { return "[已关闭全员禁言]" }
+
+// MemberMutedElem MuteEndAt 为禁言结束时间(毫秒)
+type MemberMutedElem struct {
+	GroupEventBase
+	UserID    int64 `json:"user_id,string"`
+	MuteEndAt int64 `json:"mute_end_at"`
+}
+
+func (e *MemberMutedElem) Snapshot() string { return "[成员被禁言]" }
+
+type MemberUnmutedElem struct {
+	GroupEventBase
+	UserID int64 `json:"user_id,string"`
+}
+
+func (e *MemberUnmutedElem) Snapshot() string { return "[成员被解除禁言]" }
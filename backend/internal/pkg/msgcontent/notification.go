@@ -0,0 +1,102 @@
+package msgcontent
+
+import (
+	"backend/internal/pkg/constant"
+	"errors"
+)
+
+func init() {
+	RegisterElem[FriendApplyElem](constant.MsgTypeFriendApplyNotification)
+	RegisterElem[GroupApplyElem](constant.MsgTypeGroupApplyNotification)
+	RegisterElem[AccountElem](constant.MsgTypeAccountNotification)
+}
+
+// 申请处理结果，与 FriendRequest/GroupRequest.HandleResult 一致
+const (
+	ApplyPending  int32 = 0
+	ApplyAccepted int32 = 1
+	ApplyRejected int32 = 2
+)
+
+type FriendApplyElem struct {
+	RequestID    int64  `json:"request_id,string"`
+	FromUserID   int64  `json:"from_user_id,string"`
+	ToUserID     int64  `json:"to_user_id,string"`
+	ReqMsg       string `json:"req_msg,omitempty"`
+	HandleResult int32  `json:"handle_result"`
+	HandleMsg    string `json:"handle_msg,omitempty"`
+}
+
+func (e *FriendApplyElem) Validate() error {
+	if e.RequestID == 0 || e.FromUserID == 0 || e.ToUserID == 0 {
+		return errors.New("friend apply notification missing ids")
+	}
+	return nil
+}
+
+func (e *FriendApplyElem) Snapshot() string {
+	switch e.HandleResult {
+	case ApplyAccepted:
+		return "[好友申请已通过]"
+	case ApplyRejected:
+		return "[好友申请被拒绝]"
+	}
+	return "[好友申请]"
+}
+
+type GroupApplyElem struct {
+	RequestID     int64  `json:"request_id,string"`
+	GroupID       string `json:"group_id"`
+	UserID        int64  `json:"user_id,string"` // 申请人
+	InviterUserID int64  `json:"inviter_user_id,string,omitempty"`
+	ReqMsg        string `json:"req_msg,omitempty"`
+	HandleResult  int32  `json:"handle_result"`
+	HandleUserID  int64  `json:"handle_user_id,string,omitempty"`
+	HandleMsg     string `json:"handle_msg,omitempty"`
+}
+
+func (e *GroupApplyElem) Validate() error {
+	if e.RequestID == 0 || e.GroupID == "" || e.UserID == 0 {
+		return errors.New("group apply notification missing ids")
+	}
+	return nil
+}
+
+func (e *GroupApplyElem) Snapshot() string {
+	switch e.HandleResult {
+	case ApplyAccepted:
+		return "[入群申请已通过]"
+	case ApplyRejected:
+		return "[入群申请被拒绝]"
+	}
+	return "[入群申请]"
+}
+
+// 账号事件
+const (
+	AccountEventLogin       = "login"
+	AccountEventInfoUpdated = "info_updated"
+)
+
+type AccountElem struct {
+	Event  string `json:"event"`
+	Time   int64  `json:"time"` // ms
+	Detail string `json:"detail,omitempty"`
+}
+
+func (e *AccountElem) Validate() error {
+	if e.Event == "" {
+		return errors.New("account notification event is empty")
+	}
+	return nil
+}
+
+func (e *AccountElem) Snapshot() string {
+	switch e.Event {
+	case AccountEventLogin:
+		return "[账号登录提醒]"
+	case AccountEventInfoUpdated:
+		return "[账号资料已修改]"
+	}
+	return "[账号通知]"
+}
@@ -0,0 +1,135 @@
+package msgcontent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownMsgType = errors.New("unknown msg type")
+	ErrContentTooLong = errors.New("content too long")
+)
+
+// 单条消息内容的最大长度(字节)
+const MaxContentLen = 64 * 1024
+
+// Elem 结构化消息内容，Content 以 JSON 存储
+type Elem interface {
+	Validate() error
+	Snapshot() string // 会话列表、引用、推送中展示的摘要
+}
+
+// Searchable 可被全文检索的消息内容
+type Searchable interface {
+	SearchText() string
+}
+
+// Handler 某个 MsgType 的内容校验器和摘要渲染器，SearchText 为空表示该类型不参与检索
+type Handler struct {
+	Validate   func(content string) error
+	Snapshot   func(content string) string
+	SearchText func(content string) string
+}
+
+var (
+	mu       sync.RWMutex
+	handlers = make(map[int32]Handler)
+)
+
+// Register 注册消息类型，业务可以用它扩展自定义消息。重复注册会覆盖已有的处理器。
+func Register(msgType int32, h Handler) {
+	if h.Validate == nil || h.Snapshot == nil {
+		panic(fmt.Sprintf("msgcontent: handler of msg type %d is incomplete", msgType))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[msgType] = h
+}
+
+// RegisterElem 注册以 JSON 结构 T 存储内容的消息类型，*T 需要实现 Elem，实现了 Searchable 的参与检索
+func RegisterElem[T any, PT interface {
+	*T
+	Elem
+}](msgType int32) {
+	var searchText func(content string) string
+	if _, ok := any(PT(new(T))).(Searchable); ok {
+		searchText = func(content string) string {
+			elem, err := Decode[T](content)
+			if err != nil {
+				return ""
+			}
+			return any(PT(&elem)).(Searchable).SearchText()
+		}
+	}
+	Register(msgType, Handler{
+		SearchText: searchText,
+		Validate: func(content string) error {
+			elem, err := Decode[T](content)
+			if err != nil {
+				return err
+			}
+			return PT(&elem).Validate()
+		},
+		Snapshot: func(content string) string {
+			elem, err := Decode[T](content)
+			if err != nil {
+				return ""
+			}
+			return PT(&elem).Snapshot()
+		},
+	})
+}
+
+// Decode 把消息内容解析为 T
+func Decode[T any](content string) (T, error) {
+	var elem T
+	if err := json.Unmarshal([]byte(content), &elem); err != nil {
+		return elem, fmt.Errorf("invalid content json: %w", err)
+	}
+	return elem, nil
+}
+
+// Registered 消息类型是否已注册
+func Registered(msgType int32) bool {
+	_, ok := getHandler(msgType)
+	return ok
+}
+
+// Validate 按消息类型校验内容
+func Validate(msgType int32, content string) error {
+	if len(content) > MaxContentLen {
+		return ErrContentTooLong
+	}
+	h, ok := getHandler(msgType)
+	if !ok {
+		return ErrUnknownMsgType
+	}
+	return h.Validate(content)
+}
+
+// Snapshot 生成消息摘要，未注册的类型返回 "[消息]"
+func Snapshot(msgType int32, content string) string {
+	h, ok := getHandler(msgType)
+	if !ok {
+		return "[消息]"
+	}
+	return h.Snapshot(content)
+}
+
+// SearchText 返回用于全文检索的文本，不参与检索的类型返回空串
+func SearchText(msgType int32, content string) string {
+	h, ok := getHandler(msgType)
+	if !ok || h.SearchText == nil {
+		return ""
+	}
+	return h.SearchText(content)
+}
+
+func getHandler(msgType int32) (Handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	h, ok := handlers[msgType]
+	return h, ok
+}
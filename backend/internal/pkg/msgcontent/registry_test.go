@@ -0,0 +1,105 @@
+package msgcontent
+
+import (
+	"backend/internal/pkg/constant"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateBuiltin(t *testing.T) {
+	cases := []struct {
+		name    string
+		msgType int32
+		content string
+		ok      bool
+	}{
+		{"text", constant.MsgTypeText, `{"content":"hello"}`, true},
+		{"text empty", constant.MsgTypeText, `{"content":"  "}`, false},
+		{"text plain string", constant.MsgTypeText, `hello`, false},
+		{"text null", constant.MsgTypeText, `null`, false},
+		{"image", constant.MsgTypeImage, `{"url":"http://x/1.png","width":10,"height":10}`, true},
+		{"image no url", constant.MsgTypeImage, `{"width":10}`, false},
+		{"video", constant.MsgTypeVideo, `{"url":"http://x/1.mp4","duration":3}`, true},
+		{"file", constant.MsgTypeFile, `{"url":"http://x/a.pdf","name":"a.pdf","size":1}`, true},
+		{"file no name", constant.MsgTypeFile, `{"url":"http://x/a.pdf"}`, false},
+		{"audio", constant.MsgTypeAudio, `{"url":"http://x/a.amr","duration":2}`, true},
+		{"audio zero duration", constant.MsgTypeAudio, `{"url":"http://x/a.amr"}`, false},
+		{"location", constant.MsgTypeLocation, `{"latitude":31.2,"longitude":121.5,"name":"上海"}`, true},
+		{"location out of range", constant.MsgTypeLocation, `{"latitude":91,"longitude":0}`, false},
+		{"card", constant.MsgTypeCard, `{"user_id":"123","nickname":"a"}`, true},
+		{"card no user", constant.MsgTypeCard, `{"nickname":"a"}`, false},
+		{"merge", constant.MsgTypeMerge, `{"title":"chat","messages":[{"msg_id":"1","sender_id":"2","msg_type":101,"content":"{\"content\":\"hi\"}"}]}`, true},
+		{"merge empty", constant.MsgTypeMerge, `{"title":"chat","messages":[]}`, false},
+	}
+	for _, c := range cases {
+		err := Validate(c.msgType, c.content)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: Validate() err = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestValidateUnknownAndTooLong(t *testing.T) {
+	if err := Validate(9999, `{}`); !errors.Is(err, ErrUnknownMsgType) {
+		t.Fatalf("unknown type err = %v", err)
+	}
+	long := `{"content":"` + strings.Repeat("a", MaxContentLen) + `"}`
+	if err := Validate(constant.MsgTypeText, long); !errors.Is(err, ErrContentTooLong) {
+		t.Fatalf("too long err = %v", err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	if got := Snapshot(constant.MsgTypeText, `{"content":"hi"}`); got != "hi" {
+		t.Fatalf("text snapshot = %q", got)
+	}
+	if got := Snapshot(constant.MsgTypeFile, `{"url":"u","name":"a.pdf"}`); got != "[文件] a.pdf" {
+		t.Fatalf("file snapshot = %q", got)
+	}
+	if got := Snapshot(9999, `{}`); got != "[消息]" {
+		t.Fatalf("unknown snapshot = %q", got)
+	}
+	if got := Snapshot(constant.MsgTypeFriendApplyNotification, `{"request_id":"1","from_user_id":"2","to_user_id":"3","handle_result":1}`); got != "[好友申请已通过]" {
+		t.Fatalf("friend apply snapshot = %q", got)
+	}
+}
+
+type voteElem struct {
+	Title string `json:"title"`
+}
+
+func (e *voteElem) Validate() error {
+	if e.Title == "" {
+		return errors.New("empty title")
+	}
+	return nil
+}
+
+func (e *voteElem) Snapshot() string { return "[投票] " + e.Title }
+
+func TestRegisterCustom(t *testing.T) {
+	const msgTypeVote = 1001
+	RegisterElem[voteElem](msgTypeVote)
+	if !Registered(msgTypeVote) {
+		t.Fatal("custom type not registered")
+	}
+	if err := Validate(msgTypeVote, `{"title":""}`); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if got := Snapshot(msgTypeVote, `{"title":"午饭"}`); got != "[投票] 午饭" {
+		t.Fatalf("custom snapshot = %q", got)
+	}
+}
+
+func TestSearchText(t *testing.T) {
+	if got := SearchText(constant.MsgTypeText, `{"content":"hi there"}`); got != "hi there" {
+		t.Fatalf("text search text = %q", got)
+	}
+	if got := SearchText(constant.MsgTypeFile, `{"url":"u","name":"report.pdf"}`); got != "report.pdf" {
+		t.Fatalf("file search text = %q", got)
+	}
+	if got := SearchText(constant.MsgTypeImage, `{"url":"u"}`); got != "" {
+		t.Fatalf("image should not be searchable, got %q", got)
+	}
+}
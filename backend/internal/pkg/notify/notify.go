@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"backend/internal/pkg/kafka"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// Signal 在线信令：不占用会话 seq、不落库，由 pusher 推送给在线用户。
+// 用于已读数变化、表情回应等只需要通知在线端的场景。
+type Signal struct {
+	Type           int32           `json:"type"` // 取值见 constant.MsgType*
+	UserIDs        []int64         `json:"user_ids,omitempty"`
+	ConnID         string          `json:"conn_id,omitempty"` // 非空时只推给该连接
+	ConversationID string          `json:"conversation_id,omitempty"`
+	Data           json.RawMessage `json:"data"`
+}
+
+var producer sarama.SyncProducer
+
+// Init 创建信令生产者，启动时调用一次
+func Init() error {
+	p, err := kafka.NewSyncProducer()
+	if err != nil {
+		return err
+	}
+	producer = p
+	return nil
+}
+
+// Push 将信令投递到 signal_push_topic
+func Push(ctx context.Context, typ int32, userIDs []int64, conversationID string, data any) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return push(ctx, Signal{
+		Type:           typ,
+		UserIDs:        userIDs,
+		ConversationID: conversationID,
+	}, data)
+}
+
+// PushToConn 将信令只推给用户的某个连接
+func PushToConn(ctx context.Context, typ int32, userID int64, connID string, conversationID string, data any) error {
+	if connID == "" {
+		return nil
+	}
+	return push(ctx, Signal{
+		Type:           typ,
+		UserIDs:        []int64{userID},
+		ConnID:         connID,
+		ConversationID: conversationID,
+	}, data)
+}
+
+func push(ctx context.Context, sig Signal, data any) error {
+	if producer == nil {
+		return errors.New("notify: producer not initialized, call Init first")
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	sig.Data = raw
+	value, err := json.Marshal(sig)
+	if err != nil {
+		return err
+	}
+	typ, conversationID := sig.Type, sig.ConversationID
+	_, _, err = producer.SendMessage(&sarama.ProducerMessage{
+		Topic: kafka.SignalPushTopic,
+		Key:   sarama.StringEncoder(conversationID),
+		Value: sarama.ByteEncoder(value),
+	})
+	if err != nil {
+		log.Printf("notify: push signal type=%d conversationID=%s failed: %v", typ, conversationID, err)
+	}
+	return err
+}
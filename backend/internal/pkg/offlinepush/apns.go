@@ -0,0 +1,164 @@
+package offlinepush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// Apple 要求鉴权 token 在 20~60 分钟之间刷新
+	apnsTokenTTL = 50 * time.Minute
+)
+
+// APNsProvider 通过 HTTP/2 接口推送到 iOS 设备
+type APNsProvider struct {
+	cfg      APNsConfig
+	key      *ecdsa.PrivateKey
+	endpoint string
+	client   *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNsProvider(cfg APNsConfig) (*APNsProvider, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.BundleID == "" {
+		return nil, errors.New("key_id, team_id and bundle_id are required")
+	}
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseAPNsKey(data)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := apnsProductionURL
+	if cfg.Sandbox {
+		endpoint = apnsSandboxURL
+	}
+	return &APNsProvider{cfg: cfg, key: key, endpoint: endpoint, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func parseAPNsKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid p8 key: no pem block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid p8 key: not an ecdsa key")
+	}
+	return ecKey, nil
+}
+
+func (p *APNsProvider) Name() string { return ProviderAPNs }
+
+// authToken 返回 ES256 签名的 JWT，过期前复用
+func (p *APNsProvider) authToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": p.cfg.KeyID})
+	claims, _ := json.Marshal(map[string]any{"iss": p.cfg.TeamID, "iat": now.Unix()})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, sum[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS 要求 r、s 各自定长拼接
+	size := (p.key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	p.token = signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+	p.issuedAt = now
+	return p.token, nil
+}
+
+func apnsPayload(n *Notification) ([]byte, error) {
+	aps := map[string]any{
+		"alert": map[string]string{"title": n.Title, "body": n.Body},
+		"sound": "default",
+	}
+	if n.Badge > 0 {
+		aps["badge"] = n.Badge
+	}
+	payload := map[string]any{"aps": aps}
+	for k, v := range n.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+	return json.Marshal(payload)
+}
+
+func (p *APNsProvider) Push(ctx context.Context, tokens []string, n *Notification) ([]string, error) {
+	body, err := apnsPayload(n)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := p.authToken()
+	if err != nil {
+		return nil, err
+	}
+	var invalid []string
+	var lastErr error
+	for _, token := range tokens {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/3/device/"+token, bytes.NewReader(body))
+		if err != nil {
+			return invalid, err
+		}
+		req.Header.Set("authorization", "bearer "+auth)
+		req.Header.Set("apns-topic", p.cfg.BundleID)
+		req.Header.Set("apns-push-type", "alert")
+		if n.CollapseKey != "" {
+			req.Header.Set("apns-collapse-id", n.CollapseKey)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var result struct {
+			Reason string `json:"reason"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			continue
+		}
+		json.Unmarshal(data, &result)
+		if resp.StatusCode == http.StatusGone || result.Reason == "BadDeviceToken" || result.Reason == "Unregistered" {
+			invalid = append(invalid, token)
+			continue
+		}
+		lastErr = fmt.Errorf("apns status %d: %s", resp.StatusCode, result.Reason)
+	}
+	return invalid, lastErr
+}
@@ -0,0 +1,73 @@
+package offlinepush
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Collapser 合并短时间内发往同一用户同一会话的推送，窗口结束时只推最新一条并带上条数
+type Collapser struct {
+	window time.Duration
+	flush  func(userID int64, n *Notification)
+
+	mu      sync.Mutex
+	pending map[string]*pendingPush
+}
+
+type pendingPush struct {
+	userID int64
+	last   *Notification
+	count  int
+}
+
+func NewCollapser(window time.Duration, flush func(userID int64, n *Notification)) *Collapser {
+	return &Collapser{window: window, flush: flush, pending: make(map[string]*pendingPush)}
+}
+
+// Add 加入一条推送。窗口内第一条到达时开始计时
+func (c *Collapser) Add(userID int64, n *Notification) {
+	if c.window <= 0 {
+		c.flush(userID, n)
+		return
+	}
+	key := strconv.FormatInt(userID, 10) + ":" + n.CollapseKey
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.pending[key]; ok {
+		p.last = n
+		p.count++
+		return
+	}
+	c.pending[key] = &pendingPush{userID: userID, last: n, count: 1}
+	time.AfterFunc(c.window, func() { c.fire(key) })
+}
+
+func (c *Collapser) fire(key string) {
+	c.mu.Lock()
+	p, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	n := *p.last
+	if p.count > 1 {
+		n.Body = fmt.Sprintf("[%d条] %s", p.count, n.Body)
+	}
+	c.flush(p.userID, &n)
+}
+
+// Flush 立即推送所有等待中的通知，用于退出前
+func (c *Collapser) Flush() {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.pending))
+	for key := range c.pending {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+	for _, key := range keys {
+		c.fire(key)
+	}
+}
@@ -0,0 +1,34 @@
+package offlinepush
+
+// Config 离线推送配置，只有配置了的通道才会注册
+type Config struct {
+	Enable bool `yaml:"enable"`
+	// 同一用户同一会话在窗口内的多条消息合并为一条推送(毫秒)，0 表示不合并
+	CollapseWindowMs int `yaml:"collapse_window_ms"`
+
+	APNs    APNsConfig     `yaml:"apns"`
+	FCM     FCMConfig      `yaml:"fcm"`
+	Vendors []VendorConfig `yaml:"vendors"`
+}
+
+// APNsConfig 使用 .p8 密钥的 token 鉴权
+type APNsConfig struct {
+	KeyFile  string `yaml:"key_file"`
+	KeyID    string `yaml:"key_id"`
+	TeamID   string `yaml:"team_id"`
+	BundleID string `yaml:"bundle_id"`
+	Sandbox  bool   `yaml:"sandbox"`
+}
+
+// FCMConfig 使用服务账号的 FCM HTTP v1 接口
+type FCMConfig struct {
+	CredentialsFile string `yaml:"credentials_file"`
+}
+
+// VendorConfig 厂商通道(华为、小米、OPPO、vivo 等)统一经由 HTTP 网关转发
+type VendorConfig struct {
+	Name      string `yaml:"name"`
+	URL       string `yaml:"url"`
+	Token     string `yaml:"token"`
+	TimeoutMs int    `yaml:"timeout_ms"`
+}
@@ -0,0 +1,180 @@
+package offlinepush
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmEndpoint = "https://fcm.googleapis.com"
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+type fcmCredentials struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider 通过 FCM HTTP v1 接口推送，鉴权使用服务账号换取的 OAuth2 access token
+type FCMProvider struct {
+	cred     fcmCredentials
+	key      *rsa.PrivateKey
+	endpoint string
+	client   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+func NewFCMProvider(cfg FCMConfig) (*FCMProvider, error) {
+	data, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var cred fcmCredentials
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return nil, err
+	}
+	if cred.ProjectID == "" || cred.ClientEmail == "" || cred.TokenURI == "" {
+		return nil, errors.New("credentials missing project_id, client_email or token_uri")
+	}
+	block, _ := pem.Decode([]byte(cred.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid private_key: no pem block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid private_key: not an rsa key")
+	}
+	return &FCMProvider{cred: cred, key: rsaKey, endpoint: fcmEndpoint, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (p *FCMProvider) Name() string { return ProviderFCM }
+
+// token 返回 access token，过期前一分钟刷新
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expireAt.Add(-time.Minute)) {
+		return p.accessToken, nil
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   p.cred.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.cred.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cred.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	p.accessToken = result.AccessToken
+	p.expireAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
+
+func fcmMessage(token string, n *Notification) ([]byte, error) {
+	msg := map[string]any{
+		"token":        token,
+		"notification": map[string]string{"title": n.Title, "body": n.Body},
+	}
+	if len(n.Data) > 0 {
+		msg["data"] = n.Data
+	}
+	if n.CollapseKey != "" {
+		msg["android"] = map[string]any{"collapse_key": n.CollapseKey}
+	}
+	return json.Marshal(map[string]any{"message": msg})
+}
+
+func (p *FCMProvider) Push(ctx context.Context, tokens []string, n *Notification) ([]string, error) {
+	auth, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sendURL := p.endpoint + "/v1/projects/" + p.cred.ProjectID + "/messages:send"
+	var invalid []string
+	var lastErr error
+	for _, token := range tokens {
+		body, err := fcmMessage(token, n)
+		if err != nil {
+			return invalid, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(body))
+		if err != nil {
+			return invalid, err
+		}
+		req.Header.Set("Authorization", "Bearer "+auth)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := p.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			continue
+		}
+		// 设备卸载或 token 过期时返回 404 UNREGISTERED
+		if resp.StatusCode == http.StatusNotFound || bytes.Contains(data, []byte("UNREGISTERED")) {
+			invalid = append(invalid, token)
+			continue
+		}
+		lastErr = fmt.Errorf("fcm status %d: %s", resp.StatusCode, data)
+	}
+	return invalid, lastErr
+}
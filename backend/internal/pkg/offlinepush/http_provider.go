@@ -0,0 +1,58 @@
+package offlinepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPProvider 把推送转发给厂商推送网关，网关负责对接各厂商的接口
+//
+// 请求体为 {"tokens": [...], "notification": {...}}，网关返回 {"invalid_tokens": [...]}
+type HTTPProvider struct {
+	cfg    VendorConfig
+	client *http.Client
+}
+
+func NewHTTPProvider(cfg VendorConfig) *HTTPProvider {
+	timeout := 5 * time.Second
+	if cfg.TimeoutMs > 0 {
+		timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+	return &HTTPProvider{cfg: cfg, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPProvider) Name() string { return p.cfg.Name }
+
+func (p *HTTPProvider) Push(ctx context.Context, tokens []string, n *Notification) ([]string, error) {
+	body, err := json.Marshal(map[string]any{"tokens": tokens, "notification": n})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s status %d", p.cfg.Name, resp.StatusCode)
+	}
+	var result struct {
+		InvalidTokens []string `json:"invalid_tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.InvalidTokens, nil
+}
@@ -0,0 +1,52 @@
+package offlinepush
+
+import (
+	"context"
+	"sync"
+)
+
+// MockProvider 只记录推送内容，用于测试和本地开发
+type MockProvider struct {
+	name string
+
+	mu            sync.Mutex
+	pushes        []MockPush
+	invalidTokens map[string]bool
+}
+
+type MockPush struct {
+	Tokens       []string
+	Notification Notification
+}
+
+func NewMockProvider(name string) *MockProvider {
+	return &MockProvider{name: name, invalidTokens: make(map[string]bool)}
+}
+
+func (m *MockProvider) Name() string { return m.name }
+
+func (m *MockProvider) Push(ctx context.Context, tokens []string, n *Notification) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pushes = append(m.pushes, MockPush{Tokens: append([]string(nil), tokens...), Notification: *n})
+	var invalid []string
+	for _, token := range tokens {
+		if m.invalidTokens[token] {
+			invalid = append(invalid, token)
+		}
+	}
+	return invalid, nil
+}
+
+// SetInvalid 之后推送到该 token 时返回失效
+func (m *MockProvider) SetInvalid(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidTokens[token] = true
+}
+
+func (m *MockProvider) Pushes() []MockPush {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockPush(nil), m.pushes...)
+}
@@ -0,0 +1,119 @@
+package offlinepush
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 内置通道名，设备上报 token 时使用
+const (
+	ProviderAPNs = "apns"
+	ProviderFCM  = "fcm"
+)
+
+const defaultCollapseWindow = 2 * time.Second
+
+// Notification 一条离线推送
+type Notification struct {
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Badge       int               `json:"badge,omitempty"`
+	CollapseKey string            `json:"collapse_key,omitempty"` // 同一 key 的推送在设备上只保留最新一条
+	Data        map[string]string `json:"data,omitempty"`
+}
+
+// Provider 推送通道。返回的 invalidTokens 为已失效的设备 token，调用方应删除
+type Provider interface {
+	Name() string
+	Push(ctx context.Context, tokens []string, n *Notification) (invalidTokens []string, err error)
+}
+
+var (
+	mu             sync.RWMutex
+	enabled        bool
+	collapseWindow = defaultCollapseWindow
+	providers      = make(map[string]Provider)
+)
+
+// Init 按配置注册推送通道，单个通道初始化失败不影响其他通道
+func Init(cfg Config) error {
+	mu.Lock()
+	enabled = cfg.Enable
+	if cfg.CollapseWindowMs >= 0 {
+		collapseWindow = time.Duration(cfg.CollapseWindowMs) * time.Millisecond
+	}
+	mu.Unlock()
+	if !cfg.Enable {
+		return nil
+	}
+	var errs []error
+	if cfg.APNs.KeyFile != "" {
+		if p, err := NewAPNsProvider(cfg.APNs); err != nil {
+			errs = append(errs, fmt.Errorf("apns: %w", err))
+		} else {
+			Register(p)
+		}
+	}
+	if cfg.FCM.CredentialsFile != "" {
+		if p, err := NewFCMProvider(cfg.FCM); err != nil {
+			errs = append(errs, fmt.Errorf("fcm: %w", err))
+		} else {
+			Register(p)
+		}
+	}
+	for _, v := range cfg.Vendors {
+		if v.Name == "" || v.URL == "" {
+			errs = append(errs, fmt.Errorf("vendor %q: missing name or url", v.Name))
+			continue
+		}
+		Register(NewHTTPProvider(v))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("offlinepush init: %v", errs)
+	}
+	return nil
+}
+
+// Register 注册推送通道，同名通道会被替换
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+	log.Printf("offlinepush: provider %s registered", p.Name())
+}
+
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(providers, name)
+}
+
+func GetProvider(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Enabled 是否开启离线推送
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// SetEnabled 测试或运行时开关离线推送
+func SetEnabled(enable bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = enable
+}
+
+func CollapseWindow() time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+	return collapseWindow
+}
@@ -0,0 +1,185 @@
+package offlinepush
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCollapser(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[int64][]Notification)
+	c := NewCollapser(time.Hour, func(userID int64, n *Notification) {
+		mu.Lock()
+		defer mu.Unlock()
+		got[userID] = append(got[userID], *n)
+	})
+	c.Add(1, &Notification{Body: "a", CollapseKey: "single:1_2"})
+	c.Add(1, &Notification{Body: "b", CollapseKey: "single:1_2"})
+	c.Add(1, &Notification{Body: "c", CollapseKey: "group:9"})
+	c.Add(2, &Notification{Body: "d", CollapseKey: "single:1_2"})
+	c.Flush()
+
+	if len(got[1]) != 2 || len(got[2]) != 1 || got[2][0].Body != "d" {
+		t.Fatalf("flushed = %+v", got)
+	}
+	bodies := map[string]bool{got[1][0].Body: true, got[1][1].Body: true}
+	if !bodies["[2条] b"] || !bodies["c"] {
+		t.Fatalf("collapsed bodies = %+v", bodies)
+	}
+
+	// 窗口为 0 时直接推送
+	var direct []string
+	NewCollapser(0, func(userID int64, n *Notification) { direct = append(direct, n.Body) }).Add(1, &Notification{Body: "x"})
+	if !reflect.DeepEqual(direct, []string{"x"}) {
+		t.Fatalf("direct = %v", direct)
+	}
+}
+
+func TestAPNsProvider(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFile := filepath.Join(t.TempDir(), "key.p8")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 校验 ES256 签名
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("authorization"), "bearer "), ".")
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if len(sig) != 64 || !ecdsa.Verify(&key.PublicKey, sum[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("apns-topic") != "com.example.app" || r.Header.Get("apns-collapse-id") != "single:1_2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var payload struct {
+			Aps struct {
+				Alert struct{ Title, Body string } `json:"alert"`
+			} `json:"aps"`
+			ConversationID string `json:"conversation_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload.Aps.Alert.Body != "hello" || payload.ConversationID != "single:1_2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewAPNsProvider(APNsConfig{KeyFile: keyFile, KeyID: "kid", TeamID: "team", BundleID: "com.example.app"})
+	if err != nil {
+		t.Fatalf("NewAPNsProvider: %v", err)
+	}
+	p.endpoint = srv.URL
+	invalid, err := p.Push(context.Background(), []string{"ok", "gone"}, &Notification{
+		Title: "t", Body: "hello", CollapseKey: "single:1_2", Data: map[string]string{"conversation_id": "single:1_2"},
+	})
+	if err != nil || !reflect.DeepEqual(invalid, []string{"gone"}) {
+		t.Fatalf("Push = %v, %v", invalid, err)
+	}
+}
+
+func TestFCMProvider(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	var tokenRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.FormValue("assertion") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"access","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/projects/demo/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"token":"stale"`) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cred, _ := json.Marshal(map[string]string{
+		"project_id":   "demo",
+		"client_email": "push@demo.iam.gserviceaccount.com",
+		"token_uri":    srv.URL + "/token",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	credFile := filepath.Join(t.TempDir(), "cred.json")
+	os.WriteFile(credFile, cred, 0o600)
+	p, err := NewFCMProvider(FCMConfig{CredentialsFile: credFile})
+	if err != nil {
+		t.Fatalf("NewFCMProvider: %v", err)
+	}
+	p.endpoint = srv.URL
+	n := &Notification{Title: "t", Body: "b"}
+	for i := 0; i < 2; i++ {
+		invalid, err := p.Push(context.Background(), []string{"fresh", "stale"}, n)
+		if err != nil || !reflect.DeepEqual(invalid, []string{"stale"}) {
+			t.Fatalf("Push = %v, %v", invalid, err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("access token requested %d times, want 1", tokenRequests)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tokens       []string     `json:"tokens"`
+			Notification Notification `json:"notification"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer secret" || req.Notification.Body != "b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"invalid_tokens": req.Tokens[1:]})
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(VendorConfig{Name: "huawei", URL: srv.URL, Token: "secret"})
+	invalid, err := p.Push(context.Background(), []string{"a", "b"}, &Notification{Body: "b"})
+	if err != nil || !reflect.DeepEqual(invalid, []string{"b"}) || p.Name() != "huawei" {
+		t.Fatalf("Push = %v, %v", invalid, err)
+	}
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	HighlightPre  = "<em>"
+	HighlightPost = "</em>"
+)
+
+// Highlight 在 text 中标记 keyword 的各个词（不区分大小写），
+// 并以第一个命中位置为中心截取最多 window 个字符作为摘要。window<=0 时不截取。
+// 原文做 HTML 转义，结果中只有命中标记是标签。
+func Highlight(text, keyword string, window int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	type span struct{ start, end int }
+	var spans []span
+	for _, seg := range segments(keyword) {
+		term := []rune(strings.ToLower(string(seg.text)))
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if equalRunes(lower[i:i+len(term)], term) {
+				spans = append(spans, span{i, i + len(term)})
+			}
+		}
+	}
+	from, to := 0, len(runes)
+	if len(spans) > 0 {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+		merged := spans[:1]
+		for _, s := range spans[1:] {
+			last := &merged[len(merged)-1]
+			if s.start <= last.end {
+				if s.end > last.end {
+					last.end = s.end
+				}
+				continue
+			}
+			merged = append(merged, s)
+		}
+		spans = merged
+	}
+	if window > 0 && len(runes) > window {
+		center := 0
+		if len(spans) > 0 {
+			center = spans[0].start
+		}
+		from = center - window/4
+		if from < 0 {
+			from = 0
+		}
+		to = from + window
+		if to > len(runes) {
+			to = len(runes)
+			from = to - window
+		}
+	}
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("...")
+	}
+	pos := from
+	for _, s := range spans {
+		if s.end <= from || s.start >= to {
+			continue
+		}
+		start, end := max(s.start, from), min(s.end, to)
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString(HighlightPre)
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString(HighlightPost)
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
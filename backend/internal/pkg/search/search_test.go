@@ -0,0 +1,63 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexTokens(t *testing.T) {
+	got := IndexTokens("Hello 世界你好, hello GO1!")
+	want := []string{"hello", "世", "世界", "界", "界你", "你", "你好", "好", "go1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("IndexTokens = %v, want %v", got, want)
+	}
+}
+
+func TestQueryTokens(t *testing.T) {
+	cases := map[string][]string{
+		"世界":          {"世界"},
+		"你":           {"你"},
+		"Go 世界你好":     {"go", "世界", "界你", "你好"},
+		"  ,.!":       nil,
+		"HELLO hello": {"hello"},
+	}
+	for keyword, want := range cases {
+		if got := QueryTokens(keyword); !reflect.DeepEqual(got, want) {
+			t.Errorf("QueryTokens(%q) = %v, want %v", keyword, got, want)
+		}
+	}
+}
+
+func TestQueryTokensSubsetOfIndex(t *testing.T) {
+	text := "明天下午三点开会 meeting room 302"
+	index := make(map[string]bool)
+	for _, tk := range IndexTokens(text) {
+		index[tk] = true
+	}
+	for _, keyword := range []string{"下午", "开会", "会", "Meeting", "302", "三点开会"} {
+		for _, tk := range QueryTokens(keyword) {
+			if !index[tk] {
+				t.Errorf("keyword %q token %q not indexed", keyword, tk)
+			}
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	if got := Highlight("Hello 世界", "hello", 0); got != "<em>Hello</em> 世界" {
+		t.Fatalf("Highlight = %q", got)
+	}
+	if got := Highlight("你好世界世界", "世界", 0); got != "你好<em>世界世界</em>" {
+		t.Fatalf("Highlight adjacent = %q", got)
+	}
+	if got := Highlight("abc", "xyz", 0); got != "abc" {
+		t.Fatalf("Highlight no match = %q", got)
+	}
+	got := Highlight("0123456789abcdefghijKEYklmnopqrstuvwxyz", "key", 12)
+	if got != "...hij<em>KEY</em>klmnop..." {
+		t.Fatalf("Highlight window = %q", got)
+	}
+	if got := Highlight(`<img src=x onerror="a">hi`, "hi", 0); got != "&lt;img src=x onerror=&#34;a&#34;&gt;<em>hi</em>" {
+		t.Fatalf("Highlight escape = %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+// Package search 提供消息全文检索用的分词和高亮，不依赖外部搜索服务。
+// 英文和数字按单词切分并转小写，中日韩文字按单字 + 二元组切分。
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTokenLen 单个词条最大长度(字节)，与索引表字段一致
+const MaxTokenLen = 64
+
+// maxIndexTokens 单条消息最多索引的词条数
+const maxIndexTokens = 512
+
+type segment struct {
+	text  []rune
+	start int // 在原文中的 rune 下标
+	cjk   bool
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// segments 把文本切成连续的单词段和中日韩文字段，其余字符作为分隔符
+func segments(text string) []segment {
+	var segs []segment
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			segs = append(segs, segment{text: runes[i:j], start: i, cjk: true})
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) && !isCJK(runes[j]) {
+				j++
+			}
+			segs = append(segs, segment{text: runes[i:j], start: i})
+			i = j
+		default:
+			i++
+		}
+	}
+	return segs
+}
+
+// IndexTokens 返回建索引用的去重词条
+func IndexTokens(text string) []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	add := func(t string) {
+		if t == "" || len(t) > MaxTokenLen {
+			return
+		}
+		if _, ok := seen[t]; ok {
+			return
+		}
+		seen[t] = struct{}{}
+		tokens = append(tokens, t)
+	}
+	for _, seg := range segments(text) {
+		if len(tokens) >= maxIndexTokens {
+			break
+		}
+		if !seg.cjk {
+			add(truncate(strings.ToLower(string(seg.text))))
+			continue
+		}
+		for i := range seg.text {
+			add(string(seg.text[i]))
+			if i+1 < len(seg.text) {
+				add(string(seg.text[i : i+2]))
+			}
+		}
+	}
+	if len(tokens) > maxIndexTokens {
+		tokens = tokens[:maxIndexTokens]
+	}
+	return tokens
+}
+
+// QueryTokens 返回查询用的去重词条，命中全部词条的消息才算匹配
+func QueryTokens(keyword string) []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	add := func(t string) {
+		if _, ok := seen[t]; ok || t == "" {
+			return
+		}
+		seen[t] = struct{}{}
+		tokens = append(tokens, t)
+	}
+	for _, seg := range segments(keyword) {
+		if !seg.cjk {
+			add(truncate(strings.ToLower(string(seg.text))))
+			continue
+		}
+		if len(seg.text) == 1 {
+			add(string(seg.text))
+			continue
+		}
+		for i := 0; i+1 < len(seg.text); i++ {
+			add(string(seg.text[i : i+2]))
+		}
+	}
+	return tokens
+}
+
+// truncate 按 MaxTokenLen 截断且不截断 UTF-8 字符
+func truncate(s string) string {
+	if len(s) <= MaxTokenLen {
+		return s
+	}
+	s = s[:MaxTokenLen]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
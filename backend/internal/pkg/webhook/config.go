@@ -0,0 +1,18 @@
+package webhook
+
+type Config struct {
+	URL    string `yaml:"url"`    // 业务方回调地址，为空时不启用任何回调
+	Secret string `yaml:"secret"` // 请求签名密钥
+
+	BeforeSendMsg   CallbackConfig `yaml:"before_send_msg"`
+	AfterSendMsg    CallbackConfig `yaml:"after_send_msg"`
+	BeforeAddFriend CallbackConfig `yaml:"before_add_friend"`
+	BeforeJoinGroup CallbackConfig `yaml:"before_join_group"`
+}
+
+type CallbackConfig struct {
+	Enable    bool `yaml:"enable"`
+	TimeoutMs int  `yaml:"timeout_ms"` // 默认 3000
+	// 回调失败(超时、非 2xx、响应无法解析)时是否放行，false 时拒绝本次操作
+	FailOpen bool `yaml:"fail_open"`
+}
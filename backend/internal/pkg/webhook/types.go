@@ -0,0 +1,38 @@
+package webhook
+
+type BeforeSendMsgReq struct {
+	SenderID       int64   `json:"sender_id,string"`
+	ConvType       int32   `json:"conv_type"`
+	TargetID       int64   `json:"target_id,string"`
+	ConversationID string  `json:"conversation_id"`
+	MsgType        int32   `json:"msg_type"`
+	Content        string  `json:"content"`
+	ClientMsgID    string  `json:"client_msg_id"`
+	AtUserIDs      []int64 `json:"at_user_ids,omitempty"`
+}
+
+// BeforeSendMsgResp Content 非空时替换消息内容，替换后的内容仍需符合消息类型的格式
+type BeforeSendMsgResp struct {
+	CommonResp
+	Content *string `json:"content,omitempty"`
+}
+
+type AfterSendMsgReq struct {
+	BeforeSendMsgReq
+	ServerMsgID int64 `json:"server_msg_id,string"`
+	SendTime    int64 `json:"send_time"`
+}
+
+type BeforeAddFriendReq struct {
+	FromUserID int64  `json:"from_user_id,string"`
+	ToUserID   int64  `json:"to_user_id,string"`
+	ReqMsg     string `json:"req_msg"`
+}
+
+type BeforeJoinGroupReq struct {
+	GroupID       string `json:"group_id"`
+	UserID        int64  `json:"user_id,string"`
+	InviterUserID int64  `json:"inviter_user_id,string,omitempty"`
+	JoinSource    int32  `json:"join_source"`
+	ReqMsg        string `json:"req_msg"`
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 回调命令，请求发往 URL/<command>
+const (
+	CommandBeforeSendMsg   = "beforeSendMsg"
+	CommandAfterSendMsg    = "afterSendMsg"
+	CommandBeforeAddFriend = "beforeAddFriend"
+	CommandBeforeJoinGroup = "beforeJoinGroup"
+)
+
+const (
+	HeaderCommand   = "X-Callback-Command"
+	HeaderTimestamp = "X-Callback-Timestamp" // 秒级时间戳
+	HeaderSignature = "X-Callback-Signature" // hex(HMAC-SHA256(secret, timestamp + "\n" + body))
+)
+
+const (
+	ActionContinue = 0 // 放行
+	ActionReject   = 1 // 拒绝，ErrCode/ErrMsg 返回给客户端
+)
+
+const defaultTimeout = 3 * time.Second
+
+// 业务方响应体最大长度
+const maxRespBodyLen = 1 << 20
+
+var (
+	conf       Config
+	httpClient = &http.Client{}
+
+	ErrCallbackFailed = errors.New("callback failed")
+)
+
+// RejectError 业务方拒绝了本次操作
+type RejectError struct {
+	Code int
+	Msg  string
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("callback rejected: %d %s", e.Code, e.Msg)
+}
+
+// CommonResp 所有回调响应的公共字段
+type CommonResp struct {
+	ActionCode int    `json:"action_code"`
+	ErrCode    int    `json:"err_code"`
+	ErrMsg     string `json:"err_msg"`
+}
+
+func (r *CommonResp) common() *CommonResp { return r }
+
+type Resp interface {
+	common() *CommonResp
+}
+
+func Init(cfg Config) {
+	conf = cfg
+}
+
+func callbackConfig(command string) CallbackConfig {
+	switch command {
+	case CommandBeforeSendMsg:
+		return conf.BeforeSendMsg
+	case CommandAfterSendMsg:
+		return conf.AfterSendMsg
+	case CommandBeforeAddFriend:
+		return conf.BeforeAddFriend
+	case CommandBeforeJoinGroup:
+		return conf.BeforeJoinGroup
+	}
+	return CallbackConfig{}
+}
+
+// Enabled 回调是否启用
+func Enabled(command string) bool {
+	return conf.URL != "" && callbackConfig(command).Enable
+}
+
+// Call 同步调用回调，未启用时直接返回 nil。
+// 业务方拒绝时返回 *RejectError；调用失败时按 fail_open 放行，否则返回 ErrCallbackFailed。
+// 放行的失败调用会清空 resp，调用方不会读到不完整的响应。
+func Call(ctx context.Context, command string, req any, resp Resp) error {
+	if !Enabled(command) {
+		return nil
+	}
+	cb := callbackConfig(command)
+	if err := post(ctx, command, cb, req, resp); err != nil {
+		log.Printf("webhook: %s error: %v", command, err)
+		v := reflect.ValueOf(resp).Elem()
+		v.Set(reflect.Zero(v.Type()))
+		if cb.FailOpen {
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrCallbackFailed, err)
+	}
+	if c := resp.common(); c.ActionCode != ActionContinue {
+		return &RejectError{Code: c.ErrCode, Msg: c.ErrMsg}
+	}
+	return nil
+}
+
+// CallAsync 异步通知，忽略响应，用于 after 类回调
+func CallAsync(command string, req any) {
+	if !Enabled(command) {
+		return
+	}
+	go func() {
+		if err := post(context.Background(), command, callbackConfig(command), req, &CommonResp{}); err != nil {
+			log.Printf("webhook: %s error: %v", command, err)
+		}
+	}()
+}
+
+func post(ctx context.Context, command string, cb CallbackConfig, req any, resp Resp) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	timeout := defaultTimeout
+	if cb.TimeoutMs > 0 {
+		timeout = time.Duration(cb.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(conf.URL, "/")+"/"+command, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(HeaderCommand, command)
+	httpReq.Header.Set(HeaderTimestamp, timestamp)
+	if conf.Secret != "" {
+		httpReq.Header.Set(HeaderSignature, Sign(conf.Secret, timestamp, body))
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, maxRespBodyLen))
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return fmt.Errorf("http status %d", httpResp.StatusCode)
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// Sign 计算请求签名，业务方用同样的方式校验请求来源
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
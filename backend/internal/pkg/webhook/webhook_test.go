@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	var gotCommand string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign("secret", r.Header.Get(HeaderTimestamp), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		gotCommand = r.URL.Path
+		var req BeforeSendMsgReq
+		json.Unmarshal(body, &req)
+		switch req.Content {
+		case "reject":
+			w.Write([]byte(`{"action_code":1,"err_code":50001,"err_msg":"forbidden"}`))
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"action_code":0,"content":"late"}`))
+		default:
+			w.Write([]byte(`{"action_code":0,"content":"modified"}`))
+		}
+	}))
+	defer srv.Close()
+	defer Init(Config{})
+
+	cb := CallbackConfig{Enable: true, TimeoutMs: 50}
+	Init(Config{URL: srv.URL + "/", Secret: "secret", BeforeSendMsg: cb})
+	ctx := context.Background()
+
+	var resp BeforeSendMsgResp
+	if err := Call(ctx, CommandBeforeSendMsg, BeforeSendMsgReq{Content: "hello"}, &resp); err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if gotCommand != "/"+CommandBeforeSendMsg || resp.Content == nil || *resp.Content != "modified" {
+		t.Fatalf("path = %s, resp = %+v", gotCommand, resp)
+	}
+
+	resp = BeforeSendMsgResp{}
+	var rejectErr *RejectError
+	if err := Call(ctx, CommandBeforeSendMsg, BeforeSendMsgReq{Content: "reject"}, &resp); !errors.As(err, &rejectErr) || rejectErr.Code != 50001 || rejectErr.Msg != "forbidden" {
+		t.Fatalf("reject err = %v", err)
+	}
+
+	// 超时默认拒绝
+	resp = BeforeSendMsgResp{}
+	if err := Call(ctx, CommandBeforeSendMsg, BeforeSendMsgReq{Content: "slow"}, &resp); !errors.Is(err, ErrCallbackFailed) {
+		t.Fatalf("fail closed err = %v", err)
+	}
+	cb.FailOpen = true
+	Init(Config{URL: srv.URL, Secret: "secret", BeforeSendMsg: cb})
+	resp = BeforeSendMsgResp{Content: new(string)}
+	if err := Call(ctx, CommandBeforeSendMsg, BeforeSendMsgReq{Content: "slow"}, &resp); err != nil || resp.Content != nil {
+		t.Fatalf("fail open = %+v, %v", resp, err)
+	}
+
+	// 签名错误按调用失败处理
+	Init(Config{URL: srv.URL, Secret: "wrong", BeforeSendMsg: CallbackConfig{Enable: true}})
+	if err := Call(ctx, CommandBeforeSendMsg, BeforeSendMsgReq{Content: "hello"}, &resp); !errors.Is(err, ErrCallbackFailed) {
+		t.Fatalf("bad signature err = %v", err)
+	}
+
+	// 未启用的回调不发请求
+	gotCommand = ""
+	if err := Call(ctx, CommandBeforeAddFriend, BeforeAddFriendReq{}, &CommonResp{}); err != nil || gotCommand != "" {
+		t.Fatalf("disabled callback = %s, %v", gotCommand, err)
+	}
+}
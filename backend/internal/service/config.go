@@ -0,0 +1,68 @@
+package service
+
+// Config 业务层配置
+type Config struct {
+	// 群成员数不超过该值时才提供消息级已读状态（"N人已读"）
+	GroupReadReceiptMaxMembers int `yaml:"group_read_receipt_max_members"`
+	// 同一发送者的 ClientMsgID 在该时间窗口内去重(秒)
+	MsgDedupWindowSeconds int `yaml:"msg_dedup_window_seconds"`
+	// 历史消息保留天数，0 表示永久保留。优先级：群设置 > 会话类型 > 全局
+	MsgRetentionDays        int `yaml:"msg_retention_days"`
+	SingleChatRetentionDays int `yaml:"single_chat_retention_days"`
+	GroupChatRetentionDays  int `yaml:"group_chat_retention_days"`
+	// 超出保留期的消息先归档到 message_archives 再删除
+	MsgRetentionArchive bool `yaml:"msg_retention_archive"`
+	// 批量发送/业务通知单次调用的最大接收者数，以及投递 Kafka 的限速(条/秒)
+	BatchSendMaxRecipients int `yaml:"batch_send_max_recipients"`
+	BatchSendRatePerSecond int `yaml:"batch_send_rate_per_second"`
+	// 单个会话最多置顶的消息数
+	MsgPinMaxCount int `yaml:"msg_pin_max_count"`
+	// 敏感词表文件，为空时不做内容审核；词表未单独指定动作时使用默认动作(block/mask/flag)
+	ModerationWordFile      string `yaml:"moderation_word_file"`
+	ModerationDefaultAction string `yaml:"moderation_default_action"`
+	// 检查词表文件变化的间隔(秒)
+	ModerationReloadSeconds int `yaml:"moderation_reload_seconds"`
+	// 单聊是否只允许好友之间发送，被对方拉黑时始终不能发送
+	SingleChatFriendOnly bool `yaml:"single_chat_friend_only"`
+}
+
+var conf = Config{
+	GroupReadReceiptMaxMembers: 200,
+	MsgDedupWindowSeconds:      3600,
+	BatchSendMaxRecipients:     5000,
+	BatchSendRatePerSecond:     500,
+	MsgPinMaxCount:             20,
+	ModerationDefaultAction:    "mask",
+	ModerationReloadSeconds:    30,
+}
+
+// Init 加载业务配置，未配置的项保持默认值
+func Init(cfg Config) {
+	if cfg.GroupReadReceiptMaxMembers > 0 {
+		conf.GroupReadReceiptMaxMembers = cfg.GroupReadReceiptMaxMembers
+	}
+	if cfg.MsgDedupWindowSeconds > 0 {
+		conf.MsgDedupWindowSeconds = cfg.MsgDedupWindowSeconds
+	}
+	if cfg.BatchSendMaxRecipients > 0 {
+		conf.BatchSendMaxRecipients = cfg.BatchSendMaxRecipients
+	}
+	if cfg.BatchSendRatePerSecond > 0 {
+		conf.BatchSendRatePerSecond = cfg.BatchSendRatePerSecond
+	}
+	if cfg.MsgPinMaxCount > 0 {
+		conf.MsgPinMaxCount = cfg.MsgPinMaxCount
+	}
+	if cfg.ModerationDefaultAction != "" {
+		conf.ModerationDefaultAction = cfg.ModerationDefaultAction
+	}
+	if cfg.ModerationReloadSeconds > 0 {
+		conf.ModerationReloadSeconds = cfg.ModerationReloadSeconds
+	}
+	conf.ModerationWordFile = cfg.ModerationWordFile
+	conf.MsgRetentionDays = cfg.MsgRetentionDays
+	conf.SingleChatRetentionDays = cfg.SingleChatRetentionDays
+	conf.GroupChatRetentionDays = cfg.GroupChatRetentionDays
+	conf.MsgRetentionArchive = cfg.MsgRetentionArchive
+	conf.SingleChatFriendOnly = cfg.SingleChatFriendOnly
+}
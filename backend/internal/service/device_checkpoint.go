@@ -0,0 +1,111 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TimelineCheckpointConvID 用户时间线(/msg/pull)的同步进度以空会话ID保存
+const TimelineCheckpointConvID = ""
+
+type DeviceCheckpointItem struct {
+	ConversationID string `json:"conversation_id"` // 为空表示用户时间线
+	SyncSeq        int64  `json:"sync_seq"`
+}
+
+type SetDeviceCheckpointReq struct {
+	UserID      int64                   `json:"user_id,string"`
+	DeviceID    string                  `json:"device_id"`
+	Checkpoints []*DeviceCheckpointItem `json:"checkpoints" binding:"required"`
+}
+
+type GetDeviceCheckpointReq struct {
+	UserID          int64    `json:"user_id,string"`
+	DeviceID        string   `json:"device_id"`
+	ConversationIDs []string `json:"conversation_ids"` // 为空返回该设备的全部进度
+}
+
+type GetDeviceCheckpointResp struct {
+	Checkpoints map[string]int64 `json:"checkpoints"`
+}
+
+// SetDeviceCheckpoint 上报设备的同步进度，进度只前进不回退
+func (s *MessageService) SetDeviceCheckpoint(ctx context.Context, req SetDeviceCheckpointReq) error {
+	if req.DeviceID == "" {
+		return errs.ErrInvalidParam.WithDetail("device_id is empty")
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range req.Checkpoints {
+			if item == nil || item.SyncSeq <= 0 {
+				continue
+			}
+			result := tx.Model(&model.DeviceCheckpoint{}).
+				Where("user_id = ? AND device_id = ? AND conversation_id = ? AND sync_seq < ?", req.UserID, req.DeviceID, item.ConversationID, item.SyncSeq).
+				Update("sync_seq", item.SyncSeq)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				continue
+			}
+			// 记录不存在时创建，已存在说明进度不小于上报值
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.DeviceCheckpoint{
+				UserID:         req.UserID,
+				DeviceID:       req.DeviceID,
+				ConversationID: item.ConversationID,
+				SyncSeq:        item.SyncSeq,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MessageService) GetDeviceCheckpoint(ctx context.Context, req GetDeviceCheckpointReq) (GetDeviceCheckpointResp, error) {
+	if req.DeviceID == "" {
+		return GetDeviceCheckpointResp{}, errs.ErrInvalidParam.WithDetail("device_id is empty")
+	}
+	checkpoints, err := s.getDeviceCheckpoints(ctx, req.UserID, req.DeviceID, req.ConversationIDs)
+	if err != nil {
+		return GetDeviceCheckpointResp{}, err
+	}
+	return GetDeviceCheckpointResp{Checkpoints: checkpoints}, nil
+}
+
+// getDeviceCheckpoints 返回设备在各会话的同步进度，conversationIDs 为空时返回全部
+func (s *MessageService) getDeviceCheckpoints(ctx context.Context, userID int64, deviceID string, conversationIDs []string) (map[string]int64, error) {
+	checkpoints := make(map[string]int64)
+	if deviceID == "" {
+		return checkpoints, nil
+	}
+	db := s.db.WithContext(ctx).Where("user_id = ? AND device_id = ?", userID, deviceID)
+	if len(conversationIDs) > 0 {
+		db = db.Where("conversation_id IN ?", conversationIDs)
+	}
+	var rows []model.DeviceCheckpoint
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		checkpoints[row.ConversationID] = row.SyncSeq
+	}
+	return checkpoints, nil
+}
+
+// deviceSyncSeqs 返回设备在 maxSeqs 中各会话的同步进度，超过最大 seq 的按最大 seq 返回
+func deviceSyncSeqs(maxSeqs, checkpoints map[string]int64) map[string]int64 {
+	syncSeqs := make(map[string]int64)
+	for conversationID, maxSeq := range maxSeqs {
+		syncSeq, ok := checkpoints[conversationID]
+		if !ok {
+			continue
+		}
+		syncSeqs[conversationID] = min(syncSeq, maxSeq)
+	}
+	return syncSeqs
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"backend/internal/model"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDeviceCheckpoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.DeviceCheckpoint{}, &model.UserTimeline{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	if err := s.SetDeviceCheckpoint(ctx, SetDeviceCheckpointReq{UserID: 1, DeviceID: "ios", Checkpoints: []*DeviceCheckpointItem{
+		{ConversationID: "single:1_2", SyncSeq: 10},
+		{ConversationID: TimelineCheckpointConvID, SyncSeq: 2},
+	}}); err != nil {
+		t.Fatalf("SetDeviceCheckpoint error: %v", err)
+	}
+	// 进度不回退
+	if err := s.SetDeviceCheckpoint(ctx, SetDeviceCheckpointReq{UserID: 1, DeviceID: "ios", Checkpoints: []*DeviceCheckpointItem{
+		{ConversationID: "single:1_2", SyncSeq: 5},
+	}}); err != nil {
+		t.Fatalf("SetDeviceCheckpoint error: %v", err)
+	}
+	resp, err := s.GetDeviceCheckpoint(ctx, GetDeviceCheckpointReq{UserID: 1, DeviceID: "ios"})
+	if err != nil {
+		t.Fatalf("GetDeviceCheckpoint error: %v", err)
+	}
+	if resp.Checkpoints["single:1_2"] != 10 || resp.Checkpoints[TimelineCheckpointConvID] != 2 {
+		t.Fatalf("checkpoints = %v", resp.Checkpoints)
+	}
+	// 其他设备互不影响
+	resp, err = s.GetDeviceCheckpoint(ctx, GetDeviceCheckpointReq{UserID: 1, DeviceID: "pc"})
+	if err != nil || len(resp.Checkpoints) != 0 {
+		t.Fatalf("pc checkpoints = %v, %v", resp.Checkpoints, err)
+	}
+	if err := s.SetDeviceCheckpoint(ctx, SetDeviceCheckpointReq{UserID: 1}); err == nil {
+		t.Fatalf("empty device id should fail")
+	}
+
+	// 时间线从设备进度继续
+	for seq := int64(1); seq <= 3; seq++ {
+		db.Create(&model.UserTimeline{OwnerID: 1, Seq: seq, ConversationID: "single:1_2", MsgID: seq, RefMsgSeq: seq, MsgType: 101})
+	}
+	pullResp, err := s.PullConvList(ctx, PullConvListReq{UserID: 1, DeviceID: "ios"})
+	if err != nil {
+		t.Fatalf("PullConvList error: %v", err)
+	}
+	if msgs := pullResp.PullMsgs["single:1_2"]; len(msgs) != 1 || msgs[0].Seq != 3 || pullResp.UserSeq != 3 {
+		t.Fatalf("resp = %+v", pullResp)
+	}
+}
+
+func TestDeviceSyncSeqs(t *testing.T) {
+	maxSeqs := map[string]int64{"single:1_2": 10, "group:1": 20, "group:2": 5}
+	checkpoints := map[string]int64{"single:1_2": 12, "group:1": 15, "group:3": 7}
+	syncSeqs := deviceSyncSeqs(maxSeqs, checkpoints)
+	// max_seqs 保持完整，进度单独返回
+	if len(maxSeqs) != 3 {
+		t.Fatalf("maxSeqs = %v", maxSeqs)
+	}
+	if len(syncSeqs) != 2 || syncSeqs["single:1_2"] != 10 || syncSeqs["group:1"] != 15 {
+		t.Fatalf("syncSeqs = %v", syncSeqs)
+	}
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"backend/internal/dto"
 	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
 	"context"
 
 	"gorm.io/gorm"
@@ -24,10 +26,22 @@ type ApplyToAddFriendReq struct {
 }
 
 func (s *FriendService) ApplyToAddFriend(ctx context.Context, userId int64, req ApplyToAddFriendReq) error {
-	s.db.WithContext(ctx).Create(&model.FriendRequest{
+	if err := callbackBeforeAddFriend(ctx, userId, req); err != nil {
+		return err
+	}
+	fr := model.FriendRequest{
 		FromUserID: userId,
 		ToUserID:   req.ToUserID,
 		ReqMsg:     req.ReqMsg,
+	}
+	if err := s.db.WithContext(ctx).Create(&fr).Error; err != nil {
+		return err
+	}
+	sendNotification(ctx, userId, []int64{req.ToUserID}, constant.MsgTypeFriendApplyNotification, &msgcontent.FriendApplyElem{
+		RequestID:  fr.ID,
+		FromUserID: fr.FromUserID,
+		ToUserID:   fr.ToUserID,
+		ReqMsg:     fr.ReqMsg,
 	})
 	return nil
 }
@@ -65,7 +79,17 @@ func (s *FriendService) RespondFriendApply(ctx context.Context, req RespondFrien
 			OwnerUserID:  fr.ToUserID,
 			FriendUserID: fr.FromUserID,
 		})
-	}
+		delUserRelationCache(ctx, fr.FromUserID, fr.ToUserID)
+	}
+	// 通知申请人处理结果
+	sendNotification(ctx, userId, []int64{fr.FromUserID}, constant.MsgTypeFriendApplyNotification, &msgcontent.FriendApplyElem{
+		RequestID:    fr.ID,
+		FromUserID:   fr.FromUserID,
+		ToUserID:     fr.ToUserID,
+		ReqMsg:       fr.ReqMsg,
+		HandleResult: req.HandleResult,
+		HandleMsg:    req.HandleMsg,
+	})
 	return nil
 }
 
@@ -141,6 +165,7 @@ func (s *FriendService) DeleteFriend(ctx context.Context, ownerUserID int64, fri
 	if err := s.db.WithContext(ctx).Where("owner_user_id = ? AND friend_user_id = ?", friendUserID, ownerUserID).Delete(&model.Friend{}).Error; err != nil {
 		return err
 	}
+	delUserRelationCache(ctx, ownerUserID, friendUserID)
 	return nil
 }
 
@@ -258,8 +283,7 @@ type AddBlackReq struct {
 }
 
 func (s *FriendService) AddBlack(ctx context.Context, req AddBlackReq) error {
-
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing int64
 		if err := tx.Model(&model.Black{}).Where("owner_user_id = ? AND block_user_id = ?", req.OwnerUserID, req.BlockUserID).Count(&existing).Error; err != nil {
 			return err
@@ -284,6 +308,11 @@ func (s *FriendService) AddBlack(ctx context.Context, req AddBlackReq) error {
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	delUserRelationCache(ctx, req.OwnerUserID, req.BlockUserID)
+	return nil
 }
 
 // 移除黑名单
@@ -293,7 +322,11 @@ type RemoveBlackReq struct {
 }
 
 func (s *FriendService) RemoveBlack(ctx context.Context, req RemoveBlackReq) error {
-	return s.db.WithContext(ctx).Where("owner_user_id = ? AND block_user_id = ?", req.OwnerUserID, req.BlockUserID).Delete(&model.Black{}).Error
+	if err := s.db.WithContext(ctx).Where("owner_user_id = ? AND block_user_id = ?", req.OwnerUserID, req.BlockUserID).Delete(&model.Black{}).Error; err != nil {
+		return err
+	}
+	delUserRelationCache(ctx, req.OwnerUserID, req.BlockUserID)
+	return nil
 }
 
 // 获取黑名单列表
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"context"
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// userRelation owner 对另一个用户的关系
+type userRelation struct {
+	IsFriend bool `json:"is_friend"`
+	IsBlack  bool `json:"is_black"` // owner 把对方拉黑
+}
+
+func loadUserRelation(ctx context.Context, db *gorm.DB, ownerUserID, userID int64) (userRelation, error) {
+	var rel userRelation
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.Black{}).
+		Where("owner_user_id = ? AND block_user_id = ?", ownerUserID, userID).
+		Count(&count).Error; err != nil {
+		return rel, err
+	}
+	rel.IsBlack = count > 0
+	if err := db.WithContext(ctx).Model(&model.Friend{}).
+		Where("owner_user_id = ? AND friend_user_id = ?", ownerUserID, userID).
+		Count(&count).Error; err != nil {
+		return rel, err
+	}
+	rel.IsFriend = count > 0
+	return rel, nil
+}
+
+func getUserRelation(ctx context.Context, db *gorm.DB, ownerUserID, userID int64) (userRelation, error) {
+	key := cachekey.GetUserRelationKey(strconv.FormatInt(ownerUserID, 10), strconv.FormatInt(userID, 10))
+	return redis.GetCache(key, func() (userRelation, error) {
+		return loadUserRelation(ctx, db, ownerUserID, userID)
+	}, redis.ExpireTime)
+}
+
+// delUserRelationCache 好友或黑名单变化后删除双方的关系缓存
+func delUserRelationCache(ctx context.Context, userID1, userID2 int64) {
+	id1, id2 := strconv.FormatInt(userID1, 10), strconv.FormatInt(userID2, 10)
+	if err := redis.GetRDB().Del(ctx, cachekey.GetUserRelationKey(id1, id2), cachekey.GetUserRelationKey(id2, id1)).Err(); err != nil {
+		log.Printf("delete user relation cache error: %v", err)
+	}
+}
+
+// checkSingleChatRelation 单聊发送前检查接收方是否拉黑了发送方，以及是否允许非好友发消息。服务端产生的消息不检查
+func (s *MessageService) checkSingleChatRelation(ctx context.Context, req *SendMessageReq) error {
+	if req.ConvType != constant.SingleChatType || req.serverSend || req.adminSend || req.SenderID == req.TargetID {
+		return nil
+	}
+	rel, err := getUserRelation(ctx, s.db, req.TargetID, req.SenderID)
+	if err != nil {
+		return err
+	}
+	return checkRelation(rel)
+}
+
+func checkRelation(rel userRelation) error {
+	if rel.IsBlack {
+		return errs.ErrBlockedByPeer
+	}
+	if !rel.IsFriend && conf.SingleChatFriendOnly {
+		return errs.ErrNotFriend
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUserRelation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Friend{}, &model.Black{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	db.Create(&model.Friend{OwnerUserID: 1, FriendUserID: 2})
+	db.Create(&model.Black{OwnerUserID: 3, BlockUserID: 1})
+	ctx := context.Background()
+
+	rel, err := loadUserRelation(ctx, db, 1, 2)
+	if err != nil || !rel.IsFriend || rel.IsBlack {
+		t.Fatalf("relation 1->2 = %+v, %v", rel, err)
+	}
+	rel, err = loadUserRelation(ctx, db, 3, 1)
+	if err != nil || rel.IsFriend || !rel.IsBlack {
+		t.Fatalf("relation 3->1 = %+v, %v", rel, err)
+	}
+
+	old := conf.SingleChatFriendOnly
+	defer func() { conf.SingleChatFriendOnly = old }()
+	conf.SingleChatFriendOnly = false
+	if err := checkRelation(userRelation{}); err != nil {
+		t.Fatalf("non friend allowed, err = %v", err)
+	}
+	if err := checkRelation(userRelation{IsFriend: true, IsBlack: true}); err != errs.ErrBlockedByPeer {
+		t.Fatalf("blocked err = %v", err)
+	}
+	conf.SingleChatFriendOnly = true
+	if err := checkRelation(userRelation{}); err != errs.ErrNotFriend {
+		t.Fatalf("friend only err = %v", err)
+	}
+	if err := checkRelation(userRelation{IsFriend: true}); err != nil {
+		t.Fatalf("friend err = %v", err)
+	}
+}
@@ -4,15 +4,23 @@ import (
 	"backend/internal/api/apiresp/errs"
 	"backend/internal/dto"
 	"backend/internal/model"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
 	"context"
 	"errors"
+	"log"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GroupService struct {
 	db *gorm.DB
+	// 群会话当前已分配的最大 seq，成员离开时作为可拉取的上限
+	convMaxSeq func(ctx context.Context, conversationID string) (int64, error)
 }
 
 const (
@@ -21,6 +29,13 @@ const (
 	roleMember int32 = 10
 )
 
+// 群状态
+const (
+	groupStatusNormal    int32 = 1
+	groupStatusDismissed int32 = 2
+	groupStatusMuted     int32 = 3 // 全员禁言
+)
+
 type CreateGroupReq struct {
 	GroupName         string `json:"groupName" binding:"required"`
 	AvatarURL         string `json:"avatarURL" binding:"required"`
@@ -85,7 +100,11 @@ type SetGroupMemberInfoReq struct {
 }
 
 func NewGroupService(db *gorm.DB) *GroupService {
-	return &GroupService{db: db}
+	s := &GroupService{db: db}
+	s.convMaxSeq = func(ctx context.Context, conversationID string) (int64, error) {
+		return redis.NewSeqConversationCacheRedis(s.db, redis.GetRDB()).GetMaxSeq(ctx, conversationID)
+	}
+	return s
 }
 
 func (s *GroupService) CreateGroup(ctx context.Context, req CreateGroupReq) (string, error) {
@@ -95,7 +114,7 @@ func (s *GroupService) CreateGroup(ctx context.Context, req CreateGroupReq) (str
 			GroupName:         req.GroupName,
 			AvatarURL:         req.AvatarURL,
 			Ex:                req.Ex,
-			Status:            1, // 1正常 2解散
+			Status:            groupStatusNormal,
 			CreatorUserID:     req.CreatorUserID,
 			GroupType:         req.GroupType,
 			NeedVerification:  req.NeedVerification,
@@ -116,11 +135,16 @@ func (s *GroupService) CreateGroup(ctx context.Context, req CreateGroupReq) (str
 		if err := tx.Create(&creatorMember).Error; err != nil {
 			return err
 		}
-		return nil
+		return createGroupConversations(tx, groupID, []int64{req.CreatorUserID})
 	})
 	if err != nil {
 		return "", err
 	}
+	delGroupConversationCache(ctx, groupID, req.CreatorUserID)
+	sendGroupEvent(ctx, req.CreatorUserID, groupID, constant.MsgTypeGroupCreated, &msgcontent.GroupCreatedElem{
+		GroupEventBase: groupEventBase(groupID, req.CreatorUserID),
+		GroupName:      req.GroupName,
+	})
 	return groupID, nil
 }
 
@@ -166,6 +190,9 @@ func (s *GroupService) JoinGroup(ctx context.Context, req JoinGroupReq) (bool, e
 	if memberCount > 0 {
 		return true, nil
 	}
+	if err := callbackBeforeJoinGroup(ctx, req); err != nil {
+		return false, err
+	}
 	if group.NeedVerification == 1 {
 		var pending int64
 		if err := s.db.WithContext(ctx).Model(&model.GroupRequest{}).
@@ -185,6 +212,7 @@ func (s *GroupService) JoinGroup(ctx context.Context, req JoinGroupReq) (bool, e
 			if err := s.db.WithContext(ctx).Create(&request).Error; err != nil {
 				return false, err
 			}
+			s.notifyGroupApply(ctx, request)
 		}
 		return false, nil
 	}
@@ -196,9 +224,25 @@ func (s *GroupService) JoinGroup(ctx context.Context, req JoinGroupReq) (bool, e
 		InviterUserID:  req.InviterUserID,
 		OperatorUserID: req.UserID,
 	}
-	if err := s.db.WithContext(ctx).Create(&member).Error; err != nil {
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return createGroupConversations(tx, req.GroupID, []int64{req.UserID})
+	}); err != nil {
 		return false, err
 	}
+	delGroupMemberIDsCache(ctx, req.GroupID)
+	delGroupConversationCache(ctx, req.GroupID, req.UserID)
+	operatorID := req.UserID
+	if req.InviterUserID != 0 {
+		operatorID = req.InviterUserID
+	}
+	sendGroupEvent(ctx, operatorID, req.GroupID, constant.MsgTypeMemberJoin, &msgcontent.MemberJoinElem{
+		GroupEventBase: groupEventBase(req.GroupID, operatorID),
+		UserIDs:        []int64{req.UserID},
+		InviterUserID:  req.InviterUserID,
+	})
 	return true, nil
 }
 
@@ -213,13 +257,34 @@ func (s *GroupService) QuitGroup(ctx context.Context, req QuitGroupReq) error {
 	if group.CreatorUserID == req.UserID {
 		return errs.ErrGroupOwnerCannotQuit
 	}
-	result := s.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).Delete(&model.GroupMember{})
-	if result.Error != nil {
-		return result.Error
+	maxSeq, err := s.convMaxSeq(ctx, groupConversationID(req.GroupID))
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return errs.ErrGroupMemberNotFound
+	var recvIDs []int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if recvIDs, err = pluckGroupMemberIDs(tx, req.GroupID); err != nil {
+			return err
+		}
+		result := tx.Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).Delete(&model.GroupMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errs.ErrGroupMemberNotFound
+		}
+		return hideGroupConversations(tx, req.GroupID, []int64{req.UserID}, maxSeq)
+	}); err != nil {
+		return err
 	}
+	delGroupMemberIDsCache(ctx, req.GroupID)
+	delGroupMemberMuteCache(ctx, req.GroupID, req.UserID)
+	delGroupConversationCache(ctx, req.GroupID, req.UserID)
+	sendGroupEventTo(ctx, req.UserID, req.GroupID, constant.MsgTypeMemberQuit, &msgcontent.MemberQuitElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.UserID),
+		UserID:         req.UserID,
+	}, recvIDs)
 	return nil
 }
 
@@ -263,7 +328,31 @@ func (s *GroupService) KickGroupMember(ctx context.Context, req KickGroupMemberR
 	if operator.RoleLevel <= target.RoleLevel {
 		return errs.ErrGroupPermissionDenied.WithDetail("权限不足，无法移除该成员")
 	}
-	return s.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", req.GroupID, req.TargetUserID).Delete(&model.GroupMember{}).Error
+	maxSeq, err := s.convMaxSeq(ctx, groupConversationID(req.GroupID))
+	if err != nil {
+		return err
+	}
+	var recvIDs []int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if recvIDs, err = pluckGroupMemberIDs(tx, req.GroupID); err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ? AND user_id = ?", req.GroupID, req.TargetUserID).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return hideGroupConversations(tx, req.GroupID, []int64{req.TargetUserID}, maxSeq)
+	}); err != nil {
+		return err
+	}
+	delGroupMemberIDsCache(ctx, req.GroupID)
+	delGroupMemberMuteCache(ctx, req.GroupID, req.TargetUserID)
+	delGroupConversationCache(ctx, req.GroupID, req.TargetUserID)
+	sendGroupEventTo(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeMemberKicked, &msgcontent.MemberKickedElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		UserIDs:        []int64{req.TargetUserID},
+	}, recvIDs)
+	return nil
 }
 
 func (s *GroupService) DismissGroup(ctx context.Context, req DismissGroupReq) error {
@@ -274,8 +363,17 @@ func (s *GroupService) DismissGroup(ctx context.Context, req DismissGroupReq) er
 	if group.CreatorUserID != req.OperatorUserID {
 		return errs.ErrGroupPermissionDenied.WithDetail("只有群主可以解散群组")
 	}
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&model.Group{}).Where("id = ?", group.ID).Update("status", 2).Error; err != nil {
+	maxSeq, err := s.convMaxSeq(ctx, groupConversationID(req.GroupID))
+	if err != nil {
+		return err
+	}
+	var recvIDs []int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if recvIDs, err = pluckGroupMemberIDs(tx, req.GroupID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Group{}).Where("id = ?", group.ID).Update("status", groupStatusDismissed).Error; err != nil {
 			return err
 		}
 		if err := tx.Where("group_id = ?", req.GroupID).Delete(&model.GroupMember{}).Error; err != nil {
@@ -284,8 +382,18 @@ func (s *GroupService) DismissGroup(ctx context.Context, req DismissGroupReq) er
 		if err := tx.Where("group_id = ?", req.GroupID).Delete(&model.GroupRequest{}).Error; err != nil {
 			return err
 		}
-		return nil
-	})
+		return hideGroupConversations(tx, req.GroupID, recvIDs, maxSeq)
+	}); err != nil {
+		return err
+	}
+	delGroupMemberIDsCache(ctx, req.GroupID)
+	delGroupConversationCache(ctx, req.GroupID, recvIDs...)
+	delGroupMemberMuteCache(ctx, req.GroupID, recvIDs...)
+	delGroupStatusCache(ctx, req.GroupID)
+	sendGroupEventTo(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeGroupDismissed, &msgcontent.GroupDismissedElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+	}, recvIDs)
+	return nil
 }
 
 func (s *GroupService) SetGroupInfo(ctx context.Context, req SetGroupInfoReq) error {
@@ -325,7 +433,20 @@ func (s *GroupService) SetGroupInfo(ctx context.Context, req SetGroupInfoReq) er
 	if len(updates) == 0 {
 		return nil
 	}
-	return s.db.WithContext(ctx).Model(&model.Group{}).Where("id = ?", group.ID).Updates(updates).Error
+	if err := s.db.WithContext(ctx).Model(&model.Group{}).Where("id = ?", group.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeGroupInfoSet, &msgcontent.GroupInfoSetElem{
+		GroupEventBase:    groupEventBase(req.GroupID, req.OperatorUserID),
+		GroupName:         req.GroupName,
+		AvatarURL:         req.AvatarURL,
+		Notification:      req.Notification,
+		Introduction:      req.Introduction,
+		NeedVerification:  req.NeedVerification,
+		LookMemberInfo:    req.LookMemberInfo,
+		ApplyMemberFriend: req.ApplyMemberFriend,
+	})
+	return nil
 }
 
 func (s *GroupService) SetGroupMemberInfo(ctx context.Context, req SetGroupMemberInfoReq) error {
@@ -362,7 +483,120 @@ func (s *GroupService) SetGroupMemberInfo(ctx context.Context, req SetGroupMembe
 	if len(updates) == 0 {
 		return nil
 	}
-	return s.db.WithContext(ctx).Model(&member).Updates(updates).Error
+	if err := s.db.WithContext(ctx).Model(&member).Updates(updates).Error; err != nil {
+		return err
+	}
+	if req.RoleLevel != nil {
+		delGroupMemberMuteCache(ctx, req.GroupID, req.UserID)
+	}
+	sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeMemberInfoSet, &msgcontent.MemberInfoSetElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		UserID:         req.UserID,
+		Nickname:       req.Nickname,
+		AvatarURL:      req.AvatarURL,
+		RoleLevel:      req.RoleLevel,
+	})
+	return nil
+}
+
+type RespondGroupApplicationReq struct {
+	OperatorUserID int64  `json:"-"`
+	GroupID        string `json:"-"`
+	RequestID      uint   `json:"-"`
+	HandleResult   int32  `json:"handleResult" binding:"required,oneof=1 2"` // 1：同意，2：拒绝
+	HandleMsg      string `json:"handleMsg"`
+}
+
+// RespondGroupApplication 群主或管理员处理入群申请，同意时加入群组，结果通知申请人
+func (s *GroupService) RespondGroupApplication(ctx context.Context, req RespondGroupApplicationReq) error {
+	if _, err := s.getGroup(ctx, req.GroupID); err != nil {
+		return err
+	}
+	operator, err := s.getMember(ctx, req.GroupID, req.OperatorUserID)
+	if err != nil {
+		return err
+	}
+	if operator.RoleLevel < roleAdmin {
+		return errs.ErrGroupPermissionDenied
+	}
+	var request model.GroupRequest
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND group_id = ?", req.RequestID, req.GroupID).First(&request).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.ErrGroupRequestNotFound
+			}
+			return err
+		}
+		// 条件更新，避免多个管理员同时处理
+		result := tx.Model(&model.GroupRequest{}).
+			Where("id = ? AND handle_result = 0", request.ID).
+			Updates(map[string]interface{}{
+				"handle_result":  req.HandleResult,
+				"handle_user_id": req.OperatorUserID,
+				"handled_msg":    req.HandleMsg,
+				"handled_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errs.ErrGroupRequestNotFound
+		}
+		if req.HandleResult != 1 {
+			return nil
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.GroupMember{
+			GroupID:        request.GroupID,
+			UserID:         request.UserID,
+			RoleLevel:      roleMember,
+			JoinSource:     request.JoinSource,
+			InviterUserID:  request.InviterUserID,
+			OperatorUserID: req.OperatorUserID,
+		}).Error; err != nil {
+			return err
+		}
+		return createGroupConversations(tx, request.GroupID, []int64{request.UserID})
+	}); err != nil {
+		return err
+	}
+	if req.HandleResult == 1 {
+		delGroupMemberIDsCache(ctx, req.GroupID)
+		delGroupConversationCache(ctx, request.GroupID, request.UserID)
+		sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeMemberJoin, &msgcontent.MemberJoinElem{
+			GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+			UserIDs:        []int64{request.UserID},
+			InviterUserID:  request.InviterUserID,
+		})
+	}
+	sendNotification(ctx, req.OperatorUserID, []int64{request.UserID}, constant.MsgTypeGroupApplyNotification, &msgcontent.GroupApplyElem{
+		RequestID:     int64(request.ID),
+		GroupID:       request.GroupID,
+		UserID:        request.UserID,
+		InviterUserID: request.InviterUserID,
+		ReqMsg:        request.ReqMsg,
+		HandleResult:  req.HandleResult,
+		HandleUserID:  req.OperatorUserID,
+		HandleMsg:     req.HandleMsg,
+	})
+	return nil
+}
+
+// notifyGroupApply 新的入群申请通知群主和管理员
+func (s *GroupService) notifyGroupApply(ctx context.Context, request model.GroupRequest) {
+	var adminIDs []int64
+	if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).
+		Where("group_id = ? AND role_level >= ?", request.GroupID, roleAdmin).
+		Pluck("user_id", &adminIDs).Error; err != nil {
+		log.Printf("notifyGroupApply get admins error: %v, groupID: %v", err, request.GroupID)
+		return
+	}
+	sendNotification(ctx, request.UserID, adminIDs, constant.MsgTypeGroupApplyNotification, &msgcontent.GroupApplyElem{
+		RequestID:     int64(request.ID),
+		GroupID:       request.GroupID,
+		UserID:        request.UserID,
+		InviterUserID: request.InviterUserID,
+		ReqMsg:        request.ReqMsg,
+	})
 }
 
 func (s *GroupService) getGroup(ctx context.Context, groupID string) (model.Group, error) {
@@ -373,7 +607,7 @@ func (s *GroupService) getGroup(ctx context.Context, groupID string) (model.Grou
 		}
 		return model.Group{}, err
 	}
-	if group.Status == 2 {
+	if group.Status == groupStatusDismissed {
 		return model.Group{}, errs.ErrGroupDismissed
 	}
 	return group, nil
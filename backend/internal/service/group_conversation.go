@@ -0,0 +1,76 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"context"
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 会话状态，见 model.Conversation.Status
+const (
+	conversationStatusNormal = 1
+	conversationStatusHidden = 2
+)
+
+// createGroupConversations 为加入群的成员创建群会话，退群后重新加入的恢复为正常状态并取消拉取上限
+func createGroupConversations(tx *gorm.DB, groupID string, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	conversationID := groupConversationID(groupID)
+	conversations := make([]model.Conversation, 0, len(userIDs))
+	for _, userID := range userIDs {
+		conversations = append(conversations, model.Conversation{
+			OwnerID:        userID,
+			ConversationID: conversationID,
+			ConvType:       constant.GroupChatType,
+			Status:         conversationStatusNormal,
+		})
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner_id"}, {Name: "conversation_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"status": conversationStatusNormal, "max_seq": 0}),
+	}).CreateInBatches(conversations, 500).Error
+}
+
+// hideGroupConversations 成员退群、被踢或群解散后隐藏群会话，保留已读位点等记录。
+// max_seq 记为离开时群会话的最大 seq，之后只能拉取到这里为止
+func hideGroupConversations(tx *gorm.DB, groupID string, userIDs []int64, maxSeq int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&model.Conversation{}).
+		Where("conversation_id = ? AND owner_id IN ?", groupConversationID(groupID), userIDs).
+		Updates(map[string]any{"status": conversationStatusHidden, "max_seq": maxSeq}).Error
+}
+
+func groupConversationID(groupID string) string {
+	return "group:" + groupID
+}
+
+// delGroupConversationCache 群会话创建、恢复或隐藏后删除用户的会话ID列表和该群会话的缓存
+func delGroupConversationCache(ctx context.Context, groupID string, userIDs ...int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if redis.RDB == nil {
+		log.Printf("delGroupConversationCache: redis not initialized, userIDs: %v", userIDs)
+		return
+	}
+	conversationID := groupConversationID(groupID)
+	keys := make([]string, 0, 2*len(userIDs))
+	for _, userID := range userIDs {
+		ownerID := strconv.FormatInt(userID, 10)
+		keys = append(keys, cachekey.GetConversationIDsKey(ownerID), cachekey.GetConversationKey(ownerID, conversationID))
+	}
+	if err := redis.GetRDB().Del(ctx, keys...).Err(); err != nil {
+		log.Printf("delete conversation ids cache error: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+)
+
+// sendGroupEvent 向群会话投递一条群事件消息，发送者为操作人。
+// 事件消息和普通群消息一样分配 seq 并推送，成员据此刷新本地群资料和成员缓存。
+func sendGroupEvent(ctx context.Context, operatorID int64, groupID string, msgType int32, elem any) {
+	sendGroupEventTo(ctx, operatorID, groupID, msgType, elem, nil)
+}
+
+// sendGroupEventTo 投递给指定的接收者。退群、被踢、解散时成员已被删除，
+// 由调用方在删除前取出成员列表，被移出的成员也能通过在线推送和用户时间线收到事件
+func sendGroupEventTo(ctx context.Context, operatorID int64, groupID string, msgType int32, elem any, recvIDs []int64) {
+	if systemMsgProducer == nil {
+		log.Printf("sendGroupEvent: producer not initialized, msgType: %d", msgType)
+		return
+	}
+	gid, err := strconv.ParseInt(groupID, 10, 64)
+	if err != nil {
+		log.Printf("sendGroupEvent invalid group id: %v", groupID)
+		return
+	}
+	content, err := json.Marshal(elem)
+	if err != nil {
+		log.Printf("sendGroupEvent marshal error: %v, msgType: %d", err, msgType)
+		return
+	}
+	if _, err := systemMsgProducer.Send(ctx, &SendMessageReq{
+		SenderID:   operatorID,
+		ConvType:   constant.GroupChatType,
+		TargetID:   gid,
+		MsgType:    msgType,
+		Content:    string(content),
+		RecvIDs:    recvIDs,
+		serverSend: true,
+	}); err != nil {
+		log.Printf("sendGroupEvent error: %v, msgType: %d, groupID: %v", err, msgType, groupID)
+	}
+}
+
+func groupEventBase(groupID string, operatorID int64) msgcontent.GroupEventBase {
+	return msgcontent.GroupEventBase{GroupID: groupID, OperatorUserID: operatorID}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"context"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// GetGroupMemberIDs 返回群全部成员ID，优先读缓存，推送群消息时使用
+func (s *GroupService) GetGroupMemberIDs(ctx context.Context, groupID string) ([]int64, error) {
+	return redis.GetCache(cachekey.GetGroupMemberIDsKey(groupID), func() ([]int64, error) {
+		return s.loadGroupMemberIDs(ctx, groupID)
+	}, redis.ExpireTime)
+}
+
+func (s *GroupService) loadGroupMemberIDs(ctx context.Context, groupID string) ([]int64, error) {
+	return pluckGroupMemberIDs(s.db.WithContext(ctx), groupID)
+}
+
+func pluckGroupMemberIDs(db *gorm.DB, groupID string) ([]int64, error) {
+	memberIDs := make([]int64, 0)
+	if err := db.Model(&model.GroupMember{}).
+		Where("group_id = ?", groupID).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return nil, err
+	}
+	return memberIDs, nil
+}
+
+// delGroupMemberIDsCache 成员加入/退出/被踢/解散后删除成员缓存
+func delGroupMemberIDsCache(ctx context.Context, groupID string) {
+	if redis.RDB == nil {
+		log.Printf("delGroupMemberIDsCache: redis not initialized, groupID: %v", groupID)
+		return
+	}
+	if err := redis.GetRDB().Del(ctx, cachekey.GetGroupMemberIDsKey(groupID)).Err(); err != nil {
+		log.Printf("delete group member ids cache error: %v, groupID: %v", err, groupID)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 单次禁言的最长时间
+const maxMuteSeconds = 30 * 24 * 3600
+
+// memberMute 发送前检查用到的成员角色和禁言截止时间
+type memberMute struct {
+	RoleLevel int32     `json:"role_level"`
+	MuteEndAt time.Time `json:"mute_end_at"`
+}
+
+type MuteGroupMemberReq struct {
+	GroupID        string `json:"groupID"`
+	UserID         int64  `json:"userID,string"`
+	OperatorUserID int64  `json:"operatorUserID,string"`
+	MuteSeconds    int64  `json:"muteSeconds" binding:"required"`
+}
+
+type CancelMuteGroupMemberReq struct {
+	GroupID        string `json:"groupID"`
+	UserID         int64  `json:"userID,string"`
+	OperatorUserID int64  `json:"operatorUserID,string"`
+}
+
+type MuteGroupReq struct {
+	GroupID        string `json:"groupID"`
+	OperatorUserID int64  `json:"operatorUserID,string"`
+}
+
+// MuteGroupMember 禁言成员，只能禁言角色低于自己的成员。重复禁言以最后一次为准
+func (s *GroupService) MuteGroupMember(ctx context.Context, req MuteGroupMemberReq) error {
+	if req.MuteSeconds <= 0 || req.MuteSeconds > maxMuteSeconds {
+		return errs.ErrInvalidParam.WithDetail("禁言时长不合法")
+	}
+	member, err := s.checkMuteTarget(ctx, req.GroupID, req.OperatorUserID, req.UserID)
+	if err != nil {
+		return err
+	}
+	muteEndAt := time.Now().Add(time.Duration(req.MuteSeconds) * time.Second)
+	if err := s.db.WithContext(ctx).Model(&member).Update("mute_end_at", muteEndAt).Error; err != nil {
+		return err
+	}
+	delGroupMemberMuteCache(ctx, req.GroupID, req.UserID)
+	sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeMemberMuted, &msgcontent.MemberMutedElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		UserID:         req.UserID,
+		MuteEndAt:      muteEndAt.UnixMilli(),
+	})
+	return nil
+}
+
+func (s *GroupService) CancelMuteGroupMember(ctx context.Context, req CancelMuteGroupMemberReq) error {
+	member, err := s.checkMuteTarget(ctx, req.GroupID, req.OperatorUserID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if !member.MuteEndAt.After(time.Now()) {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Model(&member).Update("mute_end_at", time.Time{}).Error; err != nil {
+		return err
+	}
+	delGroupMemberMuteCache(ctx, req.GroupID, req.UserID)
+	sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeMemberUnmuted, &msgcontent.MemberUnmutedElem{
+		GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		UserID:         req.UserID,
+	})
+	return nil
+}
+
+func (s *GroupService) checkMuteTarget(ctx context.Context, groupID string, operatorUserID, userID int64) (model.GroupMember, error) {
+	if _, err := s.getGroup(ctx, groupID); err != nil {
+		return model.GroupMember{}, err
+	}
+	operator, err := s.getMember(ctx, groupID, operatorUserID)
+	if err != nil {
+		return model.GroupMember{}, err
+	}
+	member, err := s.getMember(ctx, groupID, userID)
+	if err != nil {
+		return model.GroupMember{}, err
+	}
+	if operator.RoleLevel < roleAdmin {
+		return model.GroupMember{}, errs.ErrGroupPermissionDenied.WithDetail("没有权限禁言成员")
+	}
+	if operator.RoleLevel <= member.RoleLevel {
+		return model.GroupMember{}, errs.ErrGroupPermissionDenied.WithDetail("权限不足，无法禁言该成员")
+	}
+	return member, nil
+}
+
+// MuteGroup 开启全员禁言，群主和管理员仍可发言
+func (s *GroupService) MuteGroup(ctx context.Context, req MuteGroupReq) error {
+	return s.setGroupMuted(ctx, req, true)
+}
+
+func (s *GroupService) CancelMuteGroup(ctx context.Context, req MuteGroupReq) error {
+	return s.setGroupMuted(ctx, req, false)
+}
+
+func (s *GroupService) setGroupMuted(ctx context.Context, req MuteGroupReq, muted bool) error {
+	group, err := s.getGroup(ctx, req.GroupID)
+	if err != nil {
+		return err
+	}
+	operator, err := s.getMember(ctx, req.GroupID, req.OperatorUserID)
+	if err != nil {
+		return err
+	}
+	if operator.RoleLevel < roleAdmin {
+		return errs.ErrGroupPermissionDenied.WithDetail("没有权限设置全员禁言")
+	}
+	from, to := groupStatusNormal, groupStatusMuted
+	if !muted {
+		from, to = to, from
+	}
+	if group.Status != from {
+		return nil
+	}
+	res := s.db.WithContext(ctx).Model(&model.Group{}).Where("id = ? AND status = ?", group.ID, from).Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+	delGroupStatusCache(ctx, req.GroupID)
+	if muted {
+		sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeGroupMute, &msgcontent.GroupMutedElem{
+			GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		})
+	} else {
+		sendGroupEvent(ctx, req.OperatorUserID, req.GroupID, constant.MsgTypeGroupCancelMute, &msgcontent.GroupCancelMutedElem{
+			GroupEventBase: groupEventBase(req.GroupID, req.OperatorUserID),
+		})
+	}
+	return nil
+}
+
+// checkGroupMute 群聊发送前检查发送者是否被禁言、群是否开启了全员禁言。服务端产生的消息不检查
+func (s *MessageService) checkGroupMute(ctx context.Context, req *SendMessageReq) error {
+	if req.ConvType != constant.GroupChatType || req.serverSend || req.adminSend {
+		return nil
+	}
+	groupID := strconv.FormatInt(req.TargetID, 10)
+	member, err := getMemberMute(ctx, s.db, groupID, req.SenderID)
+	if err != nil {
+		return err
+	}
+	return checkMute(member, func() (int32, error) {
+		return getGroupStatus(ctx, s.db, groupID)
+	}, time.Now())
+}
+
+// checkMute 成员禁言优先，管理员和群主不受全员禁言限制，此时不必查询群状态
+func checkMute(member memberMute, groupStatus func() (int32, error), now time.Time) error {
+	if member.MuteEndAt.After(now) {
+		return errs.ErrGroupMemberMuted.WithDetail("解除时间 " + member.MuteEndAt.Format(time.DateTime))
+	}
+	if member.RoleLevel >= roleAdmin {
+		return nil
+	}
+	status, err := groupStatus()
+	if err != nil {
+		return err
+	}
+	if status == groupStatusMuted {
+		return errs.ErrGroupMuted
+	}
+	return nil
+}
+
+func loadMemberMute(ctx context.Context, db *gorm.DB, groupID string, userID int64) (memberMute, error) {
+	var member model.GroupMember
+	if err := db.WithContext(ctx).Select("role_level", "mute_end_at").
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return memberMute{}, errs.ErrNotConversationMember
+		}
+		return memberMute{}, err
+	}
+	return memberMute{RoleLevel: member.RoleLevel, MuteEndAt: member.MuteEndAt}, nil
+}
+
+// getMemberMute 非成员返回错误，不写缓存
+func getMemberMute(ctx context.Context, db *gorm.DB, groupID string, userID int64) (memberMute, error) {
+	return redis.GetCache(cachekey.GetGroupMemberMuteKey(groupID, strconv.FormatInt(userID, 10)), func() (memberMute, error) {
+		return loadMemberMute(ctx, db, groupID, userID)
+	}, redis.ExpireTime)
+}
+
+func loadGroupStatus(ctx context.Context, db *gorm.DB, groupID string) (int32, error) {
+	var group model.Group
+	if err := db.WithContext(ctx).Select("status").Where("id = ?", groupID).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errs.ErrGroupNotFound
+		}
+		return 0, err
+	}
+	return group.Status, nil
+}
+
+func getGroupStatus(ctx context.Context, db *gorm.DB, groupID string) (int32, error) {
+	return redis.GetCache(cachekey.GetGroupStatusKey(groupID), func() (int32, error) {
+		return loadGroupStatus(ctx, db, groupID)
+	}, redis.ExpireTime)
+}
+
+// delGroupMemberMuteCache 禁言、角色变化或成员退出后删除成员的禁言缓存
+func delGroupMemberMuteCache(ctx context.Context, groupID string, userIDs ...int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if redis.RDB == nil {
+		log.Printf("delGroupMemberMuteCache: redis not initialized, groupID: %v", groupID)
+		return
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, cachekey.GetGroupMemberMuteKey(groupID, strconv.FormatInt(userID, 10)))
+	}
+	if err := redis.GetRDB().Del(ctx, keys...).Err(); err != nil {
+		log.Printf("delete group member mute cache error: %v, groupID: %v", err, groupID)
+	}
+}
+
+// delGroupStatusCache 全员禁言开关或解散后删除群状态缓存
+func delGroupStatusCache(ctx context.Context, groupID string) {
+	if redis.RDB == nil {
+		log.Printf("delGroupStatusCache: redis not initialized, groupID: %v", groupID)
+		return
+	}
+	if err := redis.GetRDB().Del(ctx, cachekey.GetGroupStatusKey(groupID)).Err(); err != nil {
+		log.Printf("delete group status cache error: %v, groupID: %v", err, groupID)
+	}
+}
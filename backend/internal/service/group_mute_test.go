@@ -0,0 +1,99 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGroupMute(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Group{}, &model.GroupMember{}, &model.GroupRequest{}, &model.Conversation{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := NewGroupService(db)
+	ms := &MessageService{db: db}
+	ctx := context.Background()
+
+	groupID, err := s.CreateGroup(ctx, CreateGroupReq{GroupName: "g", CreatorUserID: 1})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	for _, uid := range []int64{2, 3} {
+		if _, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: uid}); err != nil {
+			t.Fatalf("JoinGroup: %v", err)
+		}
+	}
+	db.Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, 2).Update("role_level", roleAdmin)
+	gid, _ := strconv.ParseInt(groupID, 10, 64)
+	// 测试环境没有 redis，直接读库检查
+	send := func(senderID int64) error {
+		member, err := loadMemberMute(ctx, db, groupID, senderID)
+		if err != nil {
+			return err
+		}
+		return checkMute(member, func() (int32, error) {
+			return loadGroupStatus(ctx, db, groupID)
+		}, time.Now())
+	}
+
+	// 普通成员不能禁言他人，管理员不能禁言群主
+	if err := s.MuteGroupMember(ctx, MuteGroupMemberReq{GroupID: groupID, OperatorUserID: 3, UserID: 2, MuteSeconds: 60}); toCodeError(err).Code != errs.ErrCodeGroupPermissionDenied {
+		t.Fatalf("member mute err = %v", err)
+	}
+	if err := s.MuteGroupMember(ctx, MuteGroupMemberReq{GroupID: groupID, OperatorUserID: 2, UserID: 1, MuteSeconds: 60}); toCodeError(err).Code != errs.ErrCodeGroupPermissionDenied {
+		t.Fatalf("mute owner err = %v", err)
+	}
+	if err := s.MuteGroupMember(ctx, MuteGroupMemberReq{GroupID: groupID, OperatorUserID: 2, UserID: 3, MuteSeconds: 60}); err != nil {
+		t.Fatalf("MuteGroupMember: %v", err)
+	}
+	if err := send(3); toCodeError(err).Code != errs.ErrCodeGroupMemberMuted {
+		t.Fatalf("muted member send err = %v", err)
+	}
+	if err := s.CancelMuteGroupMember(ctx, CancelMuteGroupMemberReq{GroupID: groupID, OperatorUserID: 2, UserID: 3}); err != nil {
+		t.Fatalf("CancelMuteGroupMember: %v", err)
+	}
+	if err := send(3); err != nil {
+		t.Fatalf("unmuted member send err = %v", err)
+	}
+
+	// 全员禁言时管理员和群主仍可发言
+	if err := s.MuteGroup(ctx, MuteGroupReq{GroupID: groupID, OperatorUserID: 3}); toCodeError(err).Code != errs.ErrCodeGroupPermissionDenied {
+		t.Fatalf("member mute group err = %v", err)
+	}
+	if err := s.MuteGroup(ctx, MuteGroupReq{GroupID: groupID, OperatorUserID: 2}); err != nil {
+		t.Fatalf("MuteGroup: %v", err)
+	}
+	if err := send(3); err != errs.ErrGroupMuted {
+		t.Fatalf("mute all send err = %v", err)
+	}
+	if err := send(1); err != nil {
+		t.Fatalf("owner send err = %v", err)
+	}
+	if err := send(2); err != nil {
+		t.Fatalf("admin send err = %v", err)
+	}
+	// 服务端产生的群事件不受禁言限制
+	if err := ms.checkGroupMute(ctx, &SendMessageReq{SenderID: 3, ConvType: constant.GroupChatType, TargetID: gid, MsgType: constant.MsgTypeMemberQuit, serverSend: true}); err != nil {
+		t.Fatalf("group event err = %v", err)
+	}
+	if err := s.CancelMuteGroup(ctx, MuteGroupReq{GroupID: groupID, OperatorUserID: 1}); err != nil {
+		t.Fatalf("CancelMuteGroup: %v", err)
+	}
+	if err := send(3); err != nil {
+		t.Fatalf("cancel mute all send err = %v", err)
+	}
+	if err := send(4); err != errs.ErrNotConversationMember {
+		t.Fatalf("non member send err = %v", err)
+	}
+}
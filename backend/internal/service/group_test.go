@@ -0,0 +1,199 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/snowflake"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama/mocks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRespondGroupApplication(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Group{}, &model.GroupMember{}, &model.GroupRequest{}, &model.Conversation{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := NewGroupService(db)
+	ctx := context.Background()
+
+	groupID, err := s.CreateGroup(ctx, CreateGroupReq{GroupName: "g", CreatorUserID: 1, NeedVerification: 1})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	joined, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: 2})
+	if err != nil || joined {
+		t.Fatalf("JoinGroup = %v, %v, want pending", joined, err)
+	}
+	var request model.GroupRequest
+	if err := db.First(&request, "group_id = ? AND user_id = ?", groupID, 2).Error; err != nil {
+		t.Fatalf("group request not created: %v", err)
+	}
+
+	req := RespondGroupApplicationReq{OperatorUserID: 2, GroupID: groupID, RequestID: request.ID, HandleResult: 1}
+	if err := s.RespondGroupApplication(ctx, req); err == nil {
+		t.Fatal("expected error for non admin")
+	}
+	req.OperatorUserID = 1
+	if err := s.RespondGroupApplication(ctx, req); err != nil {
+		t.Fatalf("RespondGroupApplication: %v", err)
+	}
+	if _, err := s.getMember(ctx, groupID, 2); err != nil {
+		t.Fatalf("applicant not joined: %v", err)
+	}
+	// 已处理的申请不能重复处理
+	if err := s.RespondGroupApplication(ctx, req); !errors.Is(err, errs.ErrGroupRequestNotFound) {
+		t.Fatalf("err = %v, want ErrGroupRequestNotFound", err)
+	}
+}
+
+func TestGroupEventsEmitted(t *testing.T) {
+	_ = snowflake.Init(snowflake.Config{MachineID: 1})
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Group{}, &model.GroupMember{}, &model.GroupRequest{}, &model.ConversationSetting{}, &model.Conversation{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	kp := mocks.NewSyncProducer(t, nil)
+	defer kp.Close()
+	expectEvent := func(msgType int32, recvIDs ...int64) {
+		kp.ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+			var req SendMessageReq
+			if err := json.Unmarshal(val, &req); err != nil {
+				return err
+			}
+			if req.ConvType != constant.GroupChatType || req.MsgType != msgType {
+				return fmt.Errorf("got conv type %d msg type %d, want group event %d", req.ConvType, req.MsgType, msgType)
+			}
+			if fmt.Sprint(req.RecvIDs) != fmt.Sprint(recvIDs) {
+				return fmt.Errorf("msg type %d recv ids = %v, want %v", msgType, req.RecvIDs, recvIDs)
+			}
+			return nil
+		})
+	}
+	expectEvent(constant.MsgTypeGroupCreated)
+	expectEvent(constant.MsgTypeMemberJoin)
+	expectEvent(constant.MsgTypeMemberJoin)
+	// 退群、解散在删除成员前取出接收者，退出的成员也在其中
+	expectEvent(constant.MsgTypeMemberQuit, 1, 2, 3)
+	expectEvent(constant.MsgTypeGroupDismissed, 1, 3)
+
+	old := systemMsgProducer
+	defer func() { systemMsgProducer = old }()
+	InitSystemMsgProducer(NewMsgProducer(&MessageService{db: db}, kp))
+
+	s := NewGroupService(db)
+	s.convMaxSeq = func(context.Context, string) (int64, error) { return 0, nil }
+	ctx := context.Background()
+	groupID, err := s.CreateGroup(ctx, CreateGroupReq{GroupName: "g", CreatorUserID: 1})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if _, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: 2}); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if _, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: 3}); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if err := s.QuitGroup(ctx, QuitGroupReq{GroupID: groupID, UserID: 2, OperatorUserID: 2}); err != nil {
+		t.Fatalf("QuitGroup: %v", err)
+	}
+	if err := s.DismissGroup(ctx, DismissGroupReq{GroupID: groupID, OperatorUserID: 1}); err != nil {
+		t.Fatalf("DismissGroup: %v", err)
+	}
+}
+
+func TestGroupConversations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Group{}, &model.GroupMember{}, &model.GroupRequest{}, &model.Conversation{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := NewGroupService(db)
+	var groupMaxSeq int64 = 10
+	s.convMaxSeq = func(context.Context, string) (int64, error) { return groupMaxSeq, nil }
+	ctx := context.Background()
+	groupID, err := s.CreateGroup(ctx, CreateGroupReq{GroupName: "g", CreatorUserID: 1})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	for _, uid := range []int64{2, 3} {
+		if _, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: uid}); err != nil {
+			t.Fatalf("JoinGroup: %v", err)
+		}
+	}
+	get := func(userID int64) model.Conversation {
+		var conv model.Conversation
+		db.First(&conv, "owner_id = ? AND conversation_id = ?", userID, "group:"+groupID)
+		return conv
+	}
+	status := func(userID int64) int32 { return get(userID).Status }
+	for _, uid := range []int64{1, 2, 3} {
+		if got := status(uid); got != conversationStatusNormal {
+			t.Fatalf("user %d conversation status = %d, want normal", uid, got)
+		}
+	}
+
+	if err := s.QuitGroup(ctx, QuitGroupReq{GroupID: groupID, UserID: 2, OperatorUserID: 2}); err != nil {
+		t.Fatalf("QuitGroup: %v", err)
+	}
+	groupMaxSeq = 12
+	if err := s.KickGroupMember(ctx, KickGroupMemberReq{GroupID: groupID, OperatorUserID: 1, TargetUserID: 3}); err != nil {
+		t.Fatalf("KickGroupMember: %v", err)
+	}
+	if status(2) != conversationStatusHidden || status(3) != conversationStatusHidden {
+		t.Fatalf("status after quit/kick = %d, %d, want hidden", status(2), status(3))
+	}
+	// 离开后只能拉取到离开时的 seq
+	if get(2).MaxSeq != 10 || get(3).MaxSeq != 12 {
+		t.Fatalf("max seq after quit/kick = %d, %d, want 10, 12", get(2).MaxSeq, get(3).MaxSeq)
+	}
+
+	// 重新加入后会话恢复
+	if _, err := s.JoinGroup(ctx, JoinGroupReq{GroupID: groupID, UserID: 2}); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if got := get(2); got.Status != conversationStatusNormal || got.MaxSeq != 0 {
+		t.Fatalf("after rejoin status = %d, max seq = %d, want normal and 0", got.Status, got.MaxSeq)
+	}
+
+	// 已有群的成员补建会话记录，隐藏的保持不变
+	db.Where("owner_id = ?", 1).Delete(&model.Conversation{})
+	gid, _ := strconv.ParseInt(groupID, 10, 64)
+	ms := &MessageService{db: db}
+	if err := ms.InitConversation(ctx, InitConversationReq{ConvType: constant.GroupChatType, SenderID: 1, TargetID: gid}); err != nil {
+		t.Fatalf("InitConversation: %v", err)
+	}
+	if status(1) != conversationStatusNormal || status(3) != conversationStatusHidden {
+		t.Fatalf("status after init = %d, %d", status(1), status(3))
+	}
+
+	// 解散后所有成员的会话都隐藏
+	groupMaxSeq = 20
+	if err := s.DismissGroup(ctx, DismissGroupReq{GroupID: groupID, OperatorUserID: 1}); err != nil {
+		t.Fatalf("DismissGroup: %v", err)
+	}
+	for _, uid := range []int64{1, 2} {
+		if got := get(uid); got.Status != conversationStatusHidden || got.MaxSeq != 20 {
+			t.Fatalf("user %d after dismiss status = %d, max seq = %d, want hidden and 20", uid, got.Status, got.MaxSeq)
+		}
+	}
+	if got := get(3).MaxSeq; got != 12 {
+		t.Fatalf("kicked member max seq after dismiss = %d, want 12", got)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"backend/internal/api/apiresp/errs"
 	"backend/internal/model"
 	"backend/internal/pkg/cache/cachekey"
 	"backend/internal/pkg/cache/redis"
@@ -10,6 +11,8 @@ import (
 	"errors"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -19,6 +22,7 @@ type MessageService struct {
 	db           *gorm.DB
 	seqConvCache *redis.SeqConversationCacheRedis
 	seqUserCache *redis.SeqUserCacheRedis
+	dedupCache   *redis.MsgDedupCacheRedis
 }
 
 func NewMessageService(db *gorm.DB) *MessageService {
@@ -26,16 +30,40 @@ func NewMessageService(db *gorm.DB) *MessageService {
 		db:           db,
 		seqConvCache: redis.NewSeqConversationCacheRedis(db, redis.GetRDB()),
 		seqUserCache: redis.NewSeqUserCacheRedis(db, redis.GetRDB()),
+		dedupCache:   redis.NewMsgDedupCacheRedis(redis.GetRDB(), time.Duration(conf.MsgDedupWindowSeconds)*time.Second),
 	}
 }
 
 type SendMessageReq struct {
-	SenderID    int64  `json:"sender_id,string"`
-	ConvType    int32  `json:"conv_type" binding:"required"`
-	TargetID    int64  `json:"target_id,string" binding:"required"`
-	MsgType     int32  `json:"msg_type" binding:"required"`
-	ClientMsgID string `json:"client_msg_id"`
-	Content     string `json:"content" binding:"required"`
+	SenderID    int64   `json:"sender_id,string"`
+	ConvType    int32   `json:"conv_type" binding:"required"`
+	TargetID    int64   `json:"target_id,string" binding:"required"`
+	MsgType     int32   `json:"msg_type" binding:"required"`
+	ClientMsgID string  `json:"client_msg_id"`
+	Content     string  `json:"content" binding:"required"`
+	AtUserIDs   []int64 `json:"at_user_ids"`
+	IsAtAll     bool    `json:"is_at_all"`
+	RefMsgID    int64   `json:"ref_msg_id,string"` // 回复/引用的消息ID
+	// 消息存活秒数，0 时使用会话的默认设置；阅后即焚仅单聊可用，对方已读后开始计时
+	TTL           int64 `json:"ttl"`
+	BurnAfterRead bool  `json:"burn_after_read"`
+
+	// 以下由网关校验时填充，客户端传入的值会被覆盖
+	ServerMsgID  int64           `json:"server_msg_id,string"`
+	RootMsgID    int64           `json:"root_msg_id,string"`
+	RefMsg       *model.QuoteMsg `json:"ref_msg,omitempty"`
+	SenderConnID string          `json:"sender_conn_id,omitempty"`
+	MsgIncr      string          `json:"msg_incr,omitempty"`
+
+	// 管理后台发送，不受好友/黑名单和群禁言限制
+	adminSend bool
+	// 群事件的指定接收者，只有服务端消息可以设置
+	RecvIDs []int64 `json:"recv_ids,omitempty"`
+
+	// 服务端产生的群事件、系统通知等，不受发送限制，不审核也不回调
+	serverSend bool
+	// 由 ForwardMsg 生成，合并转发消息只能这样产生
+	forwardSend bool
 }
 
 // Deprecated: use im/distributor instead
@@ -107,7 +135,7 @@ func (s *MessageService) SendMessage(ctx context.Context, req SendMessageReq) er
 					RefMsgSeq:      newSeq,
 					MsgType:        req.MsgType,
 					SenderID:       req.SenderID,
-					Snapshot:       req.Content, // TODO：生成消息摘要
+					Snapshot:       msgSnapshot(req.MsgType, req.Content),
 				}
 
 				if err := tx.Create(&userTimeline).Error; err != nil {
@@ -157,19 +185,35 @@ func (s *MessageService) PullSpecifiedConv(ctx context.Context, req PullSpecifie
 }
 
 type PullConvListReq struct {
-	UserID  int64 `json:"user_id,string"`
-	UserSeq int64 `json:"user_seq"`
+	UserID   int64  `json:"user_id,string"`
+	UserSeq  int64  `json:"user_seq" form:"user_seq"`   // 上次同步到的时间线 seq，首次同步传 0
+	Num      int    `json:"num" form:"num"`             // 单次拉取条数，默认 100，最大 500
+	DeviceID string `json:"device_id" form:"device_id"` // 未传 user_seq 时从该设备上报的进度继续
 }
 type PullConvListResp struct {
 	PullMsgs map[string][]model.Message `json:"pull_msgs"`
+	UserSeq  int64                      `json:"user_seq"` // 下次同步使用的游标
+	IsEnd    bool                       `json:"is_end"`
 }
 
+// PullConvList 按用户时间线增量同步所有会话，返回消息摘要，完整内容按会话拉取
 func (s *MessageService) PullConvList(ctx context.Context, req PullConvListReq) (PullConvListResp, error) {
+	num := pullConvListNum(req.Num)
+	if req.UserSeq == 0 && req.DeviceID != "" {
+		checkpoints, err := s.getDeviceCheckpoints(ctx, req.UserID, req.DeviceID, []string{TimelineCheckpointConvID})
+		if err != nil {
+			return PullConvListResp{}, err
+		}
+		req.UserSeq = checkpoints[TimelineCheckpointConvID]
+	}
 	var userTimelines []model.UserTimeline
-	if err := s.db.WithContext(ctx).Find(&userTimelines, "owner_id = ? AND seq >= ? ORDER BY seq ASC", req.UserID, req.UserSeq).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("owner_id = ? AND seq > ?", req.UserID, req.UserSeq).
+		Order("seq ASC").Limit(num).
+		Find(&userTimelines).Error; err != nil {
 		return PullConvListResp{}, err
 	}
 	pullMsgs := make(map[string][]model.Message)
+	userSeq := req.UserSeq
 	for _, timeline := range userTimelines {
 		msgAbstract := model.Message{
 			ConversationID: timeline.ConversationID,
@@ -180,8 +224,9 @@ func (s *MessageService) PullConvList(ctx context.Context, req PullConvListReq)
 			Content:        timeline.Snapshot,
 		}
 		pullMsgs[timeline.ConversationID] = append(pullMsgs[timeline.ConversationID], msgAbstract)
+		userSeq = timeline.Seq
 	}
-	return PullConvListResp{PullMsgs: pullMsgs}, nil
+	return PullConvListResp{PullMsgs: pullMsgs, UserSeq: userSeq, IsEnd: len(userTimelines) < num}, nil
 }
 
 func (s *MessageService) DeleteConversation(ctx context.Context, userID int64, conversationID string) error {
@@ -206,15 +251,19 @@ func (s *MessageService) DeleteConversation(ctx context.Context, userID int64, c
 }
 
 type GetMaxSeqResp struct {
-	MaxSeqs map[string]int64 `json:"max_seqs"`
-	MinSeqs map[string]int64 `json:"min_seqs"`
+	MaxSeqs  map[string]int64 `json:"max_seqs"`
+	MinSeqs  map[string]int64 `json:"min_seqs"`
+	SyncSeqs map[string]int64 `json:"sync_seqs"` // 设备在各会话已同步到的 seq，客户端从其后开始拉取
 }
 
-func (s *MessageService) GetMaxSeq(ctx context.Context, userId int64) (GetMaxSeqResp, error) {
+// GetMaxSeq 返回用户各会话的最大 seq。
+// deviceID 非空时在 sync_seqs 中一并返回该设备的同步进度，max_seqs 不受影响。
+func (s *MessageService) GetMaxSeq(ctx context.Context, userId int64, deviceID string) (GetMaxSeqResp, error) {
 
 	conversationIDs, err := redis.GetCache(cachekey.GetConversationIDsKey(strconv.FormatInt(userId, 10)), func() ([]string, error) {
 		var ids []string
-		if err := s.db.WithContext(ctx).Model(&model.Conversation{}).Where("owner_id = ?", userId).Pluck("conversation_id", &ids).Error; err != nil {
+		// 已退出的群会话被隐藏，不再返回 seq
+		if err := s.db.WithContext(ctx).Model(&model.Conversation{}).Where("owner_id = ? AND status = ?", userId, conversationStatusNormal).Pluck("conversation_id", &ids).Error; err != nil {
 			return nil, err
 		}
 		return ids, nil
@@ -234,7 +283,23 @@ func (s *MessageService) GetMaxSeq(ctx context.Context, userId int64) (GetMaxSeq
 			delete(maxSeqs, conversationID)
 		}
 	}
-	return GetMaxSeqResp{MaxSeqs: maxSeqs}, nil
+	// 被保留策略或过期清理截断的会话返回可拉取的最小 seq
+	minSeqs := make(map[string]int64)
+	for conversationID := range maxSeqs {
+		minSeq, err := s.seqConvCache.GetMinSeq(ctx, conversationID)
+		if err != nil {
+			return GetMaxSeqResp{}, err
+		}
+		if minSeq > 0 {
+			minSeqs[conversationID] = minSeq
+		}
+	}
+	checkpoints, err := s.getDeviceCheckpoints(ctx, userId, deviceID, nil)
+	if err != nil {
+		return GetMaxSeqResp{}, err
+	}
+	syncSeqs := deviceSyncSeqs(maxSeqs, checkpoints)
+	return GetMaxSeqResp{MaxSeqs: maxSeqs, MinSeqs: minSeqs, SyncSeqs: syncSeqs}, nil
 }
 
 type SeqRange struct {
@@ -255,11 +320,13 @@ type PullMsgs struct {
 	Msgs   []*model.Message `json:"msgs"`
 	IsEnd  bool             `json:"is_end"`
 	EndSeq int64            `json:"end_seq"`
+	MinSeq int64            `json:"min_seq"` // 当前可拉取的最小 seq，更早的消息已被清理
 }
 
 type PullMessageBySeqsReq struct {
 	SeqRanges []*SeqRange `json:"seq_ranges"`
 	Order     PullOrder   `json:"order"`
+	DeviceID  string      `json:"device_id"` // 正序拉取且未传 begin 时从该设备已同步的位置继续
 }
 
 type PullMessageBySeqsResp struct {
@@ -267,21 +334,60 @@ type PullMessageBySeqsResp struct {
 	NotificationMsgs map[string]*PullMsgs `json:"notification_msgs"`
 }
 
+// getPullableConversation 取用户的会话记录，没有记录或已隐藏且没有拉取上限的视为非会话成员。
+// 退群的成员会话 max_seq 为离开时的群 seq，只能拉取到这里为止
+func (s *MessageService) getPullableConversation(ctx context.Context, userID int64, conversationID string) (model.Conversation, error) {
+	conversation, err := redis.GetCache(cachekey.GetConversationKey(strconv.FormatInt(userID, 10), conversationID), func() (model.Conversation, error) {
+		var conv model.Conversation
+		if err := s.db.WithContext(ctx).Where("owner_id = ? AND conversation_id = ?", userID, conversationID).First(&conv).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return model.Conversation{}, errs.ErrNotConversationMember
+			}
+			return model.Conversation{}, err
+		}
+		return conv, nil
+	}, redis.ExpireTime)
+	if err != nil {
+		return model.Conversation{}, err
+	}
+	if conversation.Status == conversationStatusHidden && conversation.MaxSeq == 0 {
+		return model.Conversation{}, errs.ErrNotConversationMember
+	}
+	return conversation, nil
+}
+
 func (s *MessageService) PullMessageBySeqs(ctx context.Context, userId int64, req PullMessageBySeqsReq) (PullMessageBySeqsResp, error) {
 	resp := PullMessageBySeqsResp{
 		Msgs:             make(map[string]*PullMsgs),
 		NotificationMsgs: make(map[string]*PullMsgs),
 	}
+	// 倒序用于翻看历史，不受同步进度限制；显式传了 begin 的区间按原样拉取
+	var checkpoints map[string]int64
+	if req.Order == PullOrderAsc && req.DeviceID != "" {
+		conversationIDs := make([]string, 0, len(req.SeqRanges))
+		for _, seqRange := range req.SeqRanges {
+			if seqRange.Begin == 0 {
+				conversationIDs = append(conversationIDs, seqRange.ConversationID)
+			}
+		}
+		if len(conversationIDs) > 0 {
+			var err error
+			if checkpoints, err = s.getDeviceCheckpoints(ctx, userId, req.DeviceID, conversationIDs); err != nil {
+				return resp, err
+			}
+		}
+	}
 
 	for _, seqRange := range req.SeqRanges {
-		log.Printf("PullMessageBySeqs processing conversationID: %v, begin: %v, end: %v, num: %v", seqRange.ConversationID, seqRange.Begin, seqRange.End, seqRange.Num)
-		conversation, err := redis.GetCache(cachekey.GetConversationKey(strconv.FormatInt(userId, 10), seqRange.ConversationID), func() (model.Conversation, error) {
-			var conv model.Conversation
-			if err := s.db.WithContext(ctx).Where("owner_id = ? AND conversation_id = ?", userId, seqRange.ConversationID).First(&conv).Error; err != nil {
-				return model.Conversation{}, err
+		if syncSeq, ok := checkpoints[seqRange.ConversationID]; ok && seqRange.Begin == 0 {
+			if syncSeq >= seqRange.End {
+				resp.Msgs[seqRange.ConversationID] = &PullMsgs{IsEnd: true, EndSeq: syncSeq}
+				continue
 			}
-			return conv, nil
-		}, redis.ExpireTime)
+			seqRange = &SeqRange{ConversationID: seqRange.ConversationID, Begin: syncSeq + 1, End: seqRange.End, Num: seqRange.Num}
+		}
+		log.Printf("PullMessageBySeqs processing conversationID: %v, begin: %v, end: %v, num: %v", seqRange.ConversationID, seqRange.Begin, seqRange.End, seqRange.Num)
+		conversation, err := s.getPullableConversation(ctx, userId, seqRange.ConversationID)
 		if err != nil {
 			log.Printf("PullMessageBySeqs get conversation error: %v, conversationID: %v", err, seqRange.ConversationID)
 			continue
@@ -300,11 +406,19 @@ func (s *MessageService) PullMessageBySeqs(ctx context.Context, userId int64, re
 		}
 		if len(msgs) == 0 {
 			log.Printf("PullMessageBySeqs no messages found, conversationID: %v, begin: %v, end: %v", seqRange.ConversationID, seqRange.Begin, seqRange.End)
+			// 请求的区间已被整体截断，告知客户端可拉取的起点
+			if minSeq > seqRange.End {
+				resp.Msgs[seqRange.ConversationID] = &PullMsgs{IsEnd: true, MinSeq: minSeq}
+			}
 			continue
 		}
+		if err := s.fillMsgReactions(ctx, userId, msgs); err != nil {
+			log.Printf("PullMessageBySeqs fill reactions error: %v, conversationID: %v", err, seqRange.ConversationID)
+		}
 		resp.Msgs[seqRange.ConversationID] = &PullMsgs{
-			Msgs:  msgs,
-			IsEnd: isEnd,
+			Msgs:   msgs,
+			IsEnd:  isEnd,
+			MinSeq: minSeq,
 		}
 	}
 
@@ -326,7 +440,7 @@ func (s *MessageService) getMsgBySeqsRange(ctx context.Context, userID int64, co
 	// "minSeq" represents the startSeq value that the user can retrieve.
 	if minSeq > end {
 		log.Printf("getMsgBySeqsRange no messages to pull, userMinSeq: %v, conMinSeq: %v, begin: %v, end: %v", userMinSeq, minSeq, begin, end)
-		return 0, 0, nil, nil
+		return minSeq, 0, nil, nil
 	}
 	maxSeq, err := s.seqConvCache.GetMaxSeq(ctx, conversationID)
 	if err != nil {
@@ -431,15 +545,25 @@ func (s *MessageService) GetSeqMessage(ctx context.Context, req GetSeqMessageReq
 			log.Printf("GetSeqMessage error: %v, conversationID: %v", err, conv.ConversationID)
 			continue
 		}
+		// 取不到 min_seq 时不能返回 0，否则客户端会重复拉取已清理的区间
+		minSeq, err := s.seqConvCache.GetMinSeq(ctx, conv.ConversationID)
+		if err != nil {
+			return GetSeqMessageResp{}, err
+		}
 		resp.Msgs[conv.ConversationID] = &PullMsgs{
 			Msgs:   msgs,
 			IsEnd:  isEnd,
 			EndSeq: endSeq,
+			MinSeq: minSeq,
 		}
 	}
 	return resp, nil
 }
 func (s *MessageService) GetMessagesBySeqWithBounds(ctx context.Context, userID int64, conversationID string, seqs []int64, pullOrder PullOrder) (bool, int64, []*model.Message, error) {
+	conversation, err := s.getPullableConversation(ctx, userID, conversationID)
+	if err != nil {
+		return false, 0, nil, err
+	}
 	userMinSeq, err := s.seqUserCache.GetSeqUserMinSeq(ctx, userID, conversationID)
 	if err != nil {
 		return false, 0, nil, err
@@ -462,6 +586,9 @@ func (s *MessageService) GetMessagesBySeqWithBounds(ctx context.Context, userID
 	if userMaxSeq != 0 && userMaxSeq < maxSeq {
 		maxSeq = userMaxSeq
 	}
+	if conversation.MaxSeq != 0 && conversation.MaxSeq < maxSeq {
+		maxSeq = conversation.MaxSeq
+	}
 	var validSeqs []int64
 	var (
 		isEnd  bool
@@ -550,6 +677,14 @@ type Seqs struct {
 	MaxSeq     int64 `json:"max_seq"`
 	HasReadSeq int64 `json:"has_read_seq"`
 	MaxSeqTime int64 `json:"max_seq_time"`
+
+	// 未读消息中的@信息
+	AtSeqs []int64 `json:"at_seqs,omitempty"` // 未读消息中@我或@所有人的 seq
+	AtMe   bool    `json:"at_me"`
+	AtAll  bool    `json:"at_all"`
+	// 会话或全局免打扰，被@时不受免打扰限制
+	IsMuted bool `json:"is_muted"`
+	Remind  bool `json:"remind"`
 }
 
 type GetConversationsHasReadAndMaxSeqResp struct {
@@ -572,19 +707,72 @@ func (s *MessageService) GetConversationsHasReadAndMaxSeq(ctx context.Context, r
 	for _, c := range conversations {
 		convMap[c.ConversationID] = c
 	}
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("global_recv_msg_opt").Where("user_id = ?", req.UserID).Limit(1).Find(&user).Error; err != nil {
+		return GetConversationsHasReadAndMaxSeqResp{}, err
+	}
 	for _, convID := range req.ConversationIDs {
 		var hasReadSeq int64
+		var isMuted bool
 		if c, ok := convMap[convID]; ok {
 			hasReadSeq = c.ReadSeq
+			isMuted = c.IsMuted
 		}
 		resp.Seqs[convID] = &Seqs{
 			MaxSeq:     maxSeqs[convID],
 			HasReadSeq: hasReadSeq,
+			IsMuted:    isMuted || user.GlobalRecvMsgOpt == 1,
 		}
 	}
+	if err := s.fillUnreadMentions(ctx, req.UserID, resp.Seqs); err != nil {
+		return GetConversationsHasReadAndMaxSeqResp{}, err
+	}
+	for _, seqs := range resp.Seqs {
+		seqs.Remind = seqs.MaxSeq > seqs.HasReadSeq && (!seqs.IsMuted || seqs.AtMe || seqs.AtAll)
+	}
 	return resp, nil
 }
 
+// fillUnreadMentions 标记每个会话未读消息中是否@了我
+func (s *MessageService) fillUnreadMentions(ctx context.Context, userID int64, seqs map[string]*Seqs) error {
+	var convIDs []string
+	minReadSeq := int64(-1)
+	for convID, seq := range seqs {
+		if seq.MaxSeq <= seq.HasReadSeq || GetConvTypeFromConversationID(convID) != constant.GroupChatType {
+			continue
+		}
+		convIDs = append(convIDs, convID)
+		if minReadSeq < 0 || seq.HasReadSeq < minReadSeq {
+			minReadSeq = seq.HasReadSeq
+		}
+	}
+	if len(convIDs) == 0 {
+		return nil
+	}
+	var ats []model.MsgAt
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id IN ? AND at_user_id IN ? AND seq > ? AND sender_id <> ?", convIDs, []int64{userID, 0}, minReadSeq, userID).
+		Order("seq ASC").
+		Find(&ats).Error; err != nil {
+		return err
+	}
+	for _, at := range ats {
+		seq := seqs[at.ConversationID]
+		if at.Seq <= seq.HasReadSeq {
+			continue
+		}
+		if at.AtUserID == 0 {
+			seq.AtAll = true
+		} else {
+			seq.AtMe = true
+		}
+		if n := len(seq.AtSeqs); n == 0 || seq.AtSeqs[n-1] != at.Seq {
+			seq.AtSeqs = append(seq.AtSeqs, at.Seq)
+		}
+	}
+	return nil
+}
+
 // ===================== Initialization Functions =====================
 
 type InitConversationReq struct {
@@ -610,7 +798,31 @@ func (s *MessageService) InitConversation(ctx context.Context, req InitConversat
 				}
 			}
 		case constant.GroupChatType:
-			// TODO: 获取所有群成员ID，为所有没有群组会话记录的成员创建会话记录
+			// 为所有没有群组会话记录的成员创建会话记录，已隐藏的保持不变
+			var memberIDs []int64
+			if err := tx.Model(&model.GroupMember{}).
+				Where("group_id = ?", strconv.FormatInt(req.TargetID, 10)).
+				Pluck("user_id", &memberIDs).Error; err != nil {
+				return err
+			}
+			conversations := make([]model.Conversation, 0, len(memberIDs))
+			for _, memberID := range memberIDs {
+				conversations = append(conversations, model.Conversation{
+					OwnerID:        memberID,
+					ConversationID: conversationID,
+					ConvType:       constant.GroupChatType,
+				})
+			}
+			if len(conversations) > 0 {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(conversations, 500).Error; err != nil {
+					return err
+				}
+			}
+		case constant.NotificationChatType:
+			conversation := model.Conversation{OwnerID: req.TargetID, ConversationID: conversationID}
+			if err := tx.FirstOrCreate(&conversation).Error; err != nil {
+				return err
+			}
 
 		default:
 			return errors.New("invalid session type")
@@ -630,7 +842,63 @@ func GetConversationID(ConvType int32, userID int64, receiverID int64) string {
 		return "single:" + strconv.FormatInt(receiverID, 10) + "_" + strconv.FormatInt(userID, 10)
 	case constant.GroupChatType:
 		return "group:" + strconv.FormatInt(receiverID, 10)
+	case constant.NotificationChatType:
+		// 通知会话归属于接收者，与发送方无关
+		return "notification:" + strconv.FormatInt(receiverID, 10)
 	default:
 		return ""
 	}
 }
+
+func GetConvTypeFromConversationID(conversationID string) int32 {
+	switch {
+	case strings.HasPrefix(conversationID, "single:"):
+		return constant.SingleChatType
+	case strings.HasPrefix(conversationID, "group:"):
+		return constant.GroupChatType
+	case strings.HasPrefix(conversationID, "notification:"):
+		return constant.NotificationChatType
+	default:
+		return 0
+	}
+}
+
+func GetGroupIDFromConversationID(conversationID string) (string, bool) {
+	groupID, ok := strings.CutPrefix(conversationID, "group:")
+	if !ok || groupID == "" {
+		return "", false
+	}
+	return groupID, true
+}
+
+func GetNotificationUserIDFromConversationID(conversationID string) (int64, bool) {
+	id, ok := strings.CutPrefix(conversationID, "notification:")
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+func GetSingleChatUserIDs(conversationID string) (int64, int64, bool) {
+	ids, ok := strings.CutPrefix(conversationID, "single:")
+	if !ok {
+		return 0, 0, false
+	}
+	a, b, ok := strings.Cut(ids, "_")
+	if !ok {
+		return 0, 0, false
+	}
+	userA, err := strconv.ParseInt(a, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	userB, err := strconv.ParseInt(b, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return userA, userB, true
+}
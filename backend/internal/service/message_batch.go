@@ -0,0 +1,148 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/constant"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type BatchSendMsgReq struct {
+	SenderID    int64   `json:"sender_id,string"`
+	ConvType    int32   `json:"conv_type" binding:"required"` // 单聊时 RecvIDs 为用户ID，群聊时为群ID
+	RecvIDs     []int64 `json:"recv_ids" binding:"required"`
+	MsgType     int32   `json:"msg_type" binding:"required"`
+	Content     string  `json:"content" binding:"required"`
+	ClientMsgID string  `json:"client_msg_id"` // 非空时按接收者派生，重试同一批次不会重复发送
+}
+
+type SendBusinessNotificationReq struct {
+	SenderID    int64   `json:"sender_id,string"`
+	RecvIDs     []int64 `json:"recv_ids" binding:"required"`
+	MsgType     int32   `json:"msg_type" binding:"required"`
+	Content     string  `json:"content" binding:"required"`
+	ClientMsgID string  `json:"client_msg_id"`
+}
+
+// BatchSendResult 单个接收者的投递结果。消息异步落库，这里只表示是否已成功投递
+type BatchSendResult struct {
+	RecvID      int64  `json:"recv_id,string"`
+	ServerMsgID int64  `json:"server_msg_id,string,omitempty"`
+	Seq         int64  `json:"seq,omitempty"` // 重复发送时返回原消息的 seq
+	Duplicated  bool   `json:"duplicated,omitempty"`
+	ErrCode     int    `json:"err_code"`
+	ErrMsg      string `json:"err_msg,omitempty"`
+}
+
+type BatchSendMsgResp struct {
+	SuccessCount int                `json:"success_count"`
+	FailedCount  int                `json:"failed_count"`
+	Results      []*BatchSendResult `json:"results"`
+}
+
+// BatchSendMsg 向多个用户或群发送同一条消息
+func (p *MsgProducer) BatchSendMsg(ctx context.Context, req BatchSendMsgReq) (*BatchSendMsgResp, error) {
+	if req.ConvType != constant.SingleChatType && req.ConvType != constant.GroupChatType {
+		return nil, errs.ErrInvalidParam.WithDetail("invalid conv type")
+	}
+	return p.batchSend(ctx, SendMessageReq{
+		SenderID:    req.SenderID,
+		ConvType:    req.ConvType,
+		MsgType:     req.MsgType,
+		Content:     req.Content,
+		ClientMsgID: req.ClientMsgID,
+	}, req.RecvIDs)
+}
+
+// SendBusinessNotification 向用户的通知会话发送业务通知
+func (p *MsgProducer) SendBusinessNotification(ctx context.Context, req SendBusinessNotificationReq) (*BatchSendMsgResp, error) {
+	return p.batchSend(ctx, SendMessageReq{
+		SenderID:    req.SenderID,
+		ConvType:    constant.NotificationChatType,
+		MsgType:     req.MsgType,
+		Content:     req.Content,
+		ClientMsgID: req.ClientMsgID,
+	}, req.RecvIDs)
+}
+
+// batchSend 按接收者逐条走正常的发送流程，投递按 BatchSendRatePerSecond 限速。
+// 单个接收者失败不影响其他接收者；ctx 取消后剩余接收者记为失败。
+func (p *MsgProducer) batchSend(ctx context.Context, base SendMessageReq, recvIDs []int64) (*BatchSendMsgResp, error) {
+	recvIDs = uniqueIDs(recvIDs)
+	if len(recvIDs) == 0 {
+		return nil, errs.ErrInvalidParam.WithDetail("recv_ids is empty")
+	}
+	if len(recvIDs) > conf.BatchSendMaxRecipients {
+		return nil, errs.ErrInvalidParam.WithDetail(fmt.Sprintf("接收者不能超过 %d 个", conf.BatchSendMaxRecipients))
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(conf.BatchSendRatePerSecond))
+	defer ticker.Stop()
+
+	resp := &BatchSendMsgResp{Results: make([]*BatchSendResult, 0, len(recvIDs))}
+	for i, recvID := range recvIDs {
+		result := &BatchSendResult{RecvID: recvID}
+		resp.Results = append(resp.Results, result)
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				setBatchSendErr(result, ctx.Err())
+				continue
+			case <-ticker.C:
+			}
+		}
+		req := base
+		req.TargetID = recvID
+		req.adminSend = true
+		if base.ClientMsgID != "" {
+			req.ClientMsgID = fmt.Sprintf("%s-%d", base.ClientMsgID, recvID)
+		}
+		sendResp, err := p.Send(ctx, &req)
+		if err != nil {
+			setBatchSendErr(result, err)
+			continue
+		}
+		result.ServerMsgID = sendResp.ServerMsgID
+		result.Seq = sendResp.Seq
+		result.Duplicated = sendResp.Duplicated
+	}
+	for _, result := range resp.Results {
+		if result.ErrCode == 0 {
+			resp.SuccessCount++
+		} else {
+			resp.FailedCount++
+		}
+	}
+	return resp, nil
+}
+
+func setBatchSendErr(result *BatchSendResult, err error) {
+	codeErr := toCodeError(err)
+	result.ErrCode, result.ErrMsg = codeErr.Code, codeErr.Msg
+}
+
+// toCodeError 非业务错误统一转为服务器内部错误
+func toCodeError(err error) *errs.CodeError {
+	var codeErr *errs.CodeError
+	if !errors.As(err, &codeErr) {
+		codeErr = errs.ErrInternalServer.WithDetail(err.Error())
+	}
+	return codeErr
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
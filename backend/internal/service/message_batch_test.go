@@ -0,0 +1,76 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/snowflake"
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama/mocks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBatchSendMsg(t *testing.T) {
+	_ = snowflake.Init(snowflake.Config{MachineID: 1})
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.ConversationSetting{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	kp := mocks.NewSyncProducer(t, nil)
+	defer kp.Close()
+	kp.ExpectSendMessageAndSucceed()
+	kp.ExpectSendMessageAndSucceed()
+	p := NewMsgProducer(&MessageService{db: db}, kp)
+	ctx := context.Background()
+
+	resp, err := p.BatchSendMsg(ctx, BatchSendMsgReq{
+		SenderID: 1,
+		ConvType: constant.SingleChatType,
+		RecvIDs:  []int64{2, 3, 2, 0},
+		MsgType:  constant.MsgTypeText,
+		Content:  `{"content":"hi"}`,
+	})
+	if err != nil {
+		t.Fatalf("BatchSendMsg: %v", err)
+	}
+	if resp.SuccessCount != 2 || resp.FailedCount != 0 || len(resp.Results) != 2 {
+		t.Fatalf("unexpected resp: %+v", resp)
+	}
+
+	// 内容不合法时逐个接收者返回错误，不投递
+	resp, err = p.SendBusinessNotification(ctx, SendBusinessNotificationReq{
+		SenderID: 1,
+		RecvIDs:  []int64{2},
+		MsgType:  constant.MsgTypeText,
+		Content:  `not json`,
+	})
+	if err != nil {
+		t.Fatalf("SendBusinessNotification: %v", err)
+	}
+	if resp.FailedCount != 1 || resp.Results[0].ErrCode != errs.ErrCodeMsgContentInvalid {
+		t.Fatalf("unexpected resp: %+v", resp.Results[0])
+	}
+
+	if _, err := p.BatchSendMsg(ctx, BatchSendMsgReq{ConvType: constant.NotificationChatType, RecvIDs: []int64{2}}); err == nil {
+		t.Fatal("expected error for notification conv type")
+	}
+}
+
+func TestNotificationConversationID(t *testing.T) {
+	convID := GetConversationID(constant.NotificationChatType, 1, 42)
+	if convID != "notification:42" {
+		t.Fatalf("convID = %q", convID)
+	}
+	if GetConvTypeFromConversationID(convID) != constant.NotificationChatType {
+		t.Fatal("wrong conv type")
+	}
+	if id, ok := GetNotificationUserIDFromConversationID(convID); !ok || id != 42 {
+		t.Fatalf("owner = %d, %v", id, ok)
+	}
+}
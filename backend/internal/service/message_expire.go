@@ -0,0 +1,201 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/notify"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 消息最长存活 30 天
+	maxMsgTTL int64 = 30 * 24 * 3600
+	// 阅后即焚未指定时长时的默认值(秒)
+	defaultBurnAfterReadTTL int64 = 30
+)
+
+// MsgExpiredNotify 推送给会话成员的消息过期通知，客户端据此删除本地消息
+type MsgExpiredNotify struct {
+	ConversationID string  `json:"conversation_id"`
+	Seqs           []int64 `json:"seqs"`
+	MinSeq         int64   `json:"min_seq"` // 过期清理后会话可拉取的最小 seq
+}
+
+// fillMsgTTL 校验消息存活时间，未指定时使用会话的默认设置
+func (s *MessageService) fillMsgTTL(ctx context.Context, req *SendMessageReq) error {
+	if req.TTL < 0 || req.TTL > maxMsgTTL {
+		return errs.ErrInvalidParam.WithDetail("invalid ttl")
+	}
+	if req.BurnAfterRead {
+		if req.ConvType != constant.SingleChatType {
+			return errs.ErrInvalidParam.WithDetail("阅后即焚仅支持单聊")
+		}
+		if req.TTL == 0 {
+			req.TTL = defaultBurnAfterReadTTL
+		}
+		return nil
+	}
+	if req.TTL > 0 {
+		return nil
+	}
+	ttl, err := s.getConversationMsgTTL(ctx, GetConversationID(req.ConvType, req.SenderID, req.TargetID))
+	if err != nil {
+		return err
+	}
+	req.TTL = ttl
+	return nil
+}
+
+func (s *MessageService) getConversationMsgTTL(ctx context.Context, conversationID string) (int64, error) {
+	var setting model.ConversationSetting
+	if err := s.db.WithContext(ctx).Select("msg_ttl").
+		Where("conversation_id = ?", conversationID).
+		First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return setting.MsgTTL, nil
+}
+
+type SetConversationMsgTTLReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	TTL            int64  `json:"ttl"` // 秒，0 表示关闭
+}
+
+// SetConversationMsgTTL 设置会话新消息的默认存活时间，已发送的消息不受影响。
+// 单聊双方都可设置，群聊要求群主或管理员。
+func (s *MessageService) SetConversationMsgTTL(ctx context.Context, req SetConversationMsgTTLReq) error {
+	if req.TTL < 0 || req.TTL > maxMsgTTL {
+		return errs.ErrInvalidParam.WithDetail("invalid ttl")
+	}
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return err
+	}
+	if groupID, ok := GetGroupIDFromConversationID(req.ConversationID); ok {
+		if err := s.checkGroupAdmin(ctx, groupID, req.UserID); err != nil {
+			return err
+		}
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"msg_ttl", "updated_by", "update_time"}),
+	}).Create(&model.ConversationSetting{
+		ConversationID: req.ConversationID,
+		MsgTTL:         req.TTL,
+		UpdatedBy:      req.UserID,
+	}).Error
+}
+
+// startBurnAfterRead 对方已读后开始阅后即焚计时，只处理本次新读到的、尚未开始计时的消息
+func (s *MessageService) startBurnAfterRead(ctx context.Context, conversationID string, readerID, oldReadSeq, newReadSeq int64) error {
+	return s.db.WithContext(ctx).Model(&model.Message{}).
+		Where("conversation_id = ? AND seq > ? AND seq <= ? AND burn_after_read = ? AND expire_at = 0 AND sender_id <> ?",
+			conversationID, oldReadSeq, newReadSeq, true, readerID).
+		Update("expire_at", gorm.Expr("? + ttl * 1000", time.Now().UnixMilli())).Error
+}
+
+// PurgeExpiredMessages 删除已过期的消息及其关联数据，推进会话 MinSeq 并通知会话成员。
+// 返回本次处理的消息数。
+func (s *MessageService) PurgeExpiredMessages(ctx context.Context, limit int) (int, error) {
+	var msgs []*model.Message
+	if err := s.db.WithContext(ctx).Select("id", "conversation_id", "seq").
+		Where("expire_at > 0 AND expire_at <= ?", time.Now().UnixMilli()).
+		Order("expire_at").Limit(limit).
+		Find(&msgs).Error; err != nil {
+		return 0, err
+	}
+	byConv := make(map[string][]*model.Message)
+	for _, msg := range msgs {
+		byConv[msg.ConversationID] = append(byConv[msg.ConversationID], msg)
+	}
+	for conversationID, convMsgs := range byConv {
+		minSeq, err := s.deleteExpiredMsgs(ctx, conversationID, convMsgs)
+		if err != nil {
+			return 0, err
+		}
+		seqs := make([]int64, 0, len(convMsgs))
+		keys := make([]string, 0, len(convMsgs))
+		for _, msg := range convMsgs {
+			seqs = append(seqs, msg.Seq)
+			keys = append(keys, cachekey.GetMsgCacheKey(conversationID, msg.Seq))
+		}
+		if err := redis.GetRDB().Del(ctx, keys...).Err(); err != nil {
+			log.Printf("PurgeExpiredMessages del msg cache error: %v, conversationID: %v", err, conversationID)
+		}
+		if err := s.seqConvCache.SetMinSeq(ctx, conversationID, minSeq); err != nil {
+			log.Printf("PurgeExpiredMessages set min seq error: %v, conversationID: %v", err, conversationID)
+		}
+		s.pushMsgExpired(ctx, conversationID, seqs, minSeq)
+	}
+	return len(msgs), nil
+}
+
+// deleteExpiredMsgs 删除一个会话内的过期消息，返回会话新的 MinSeq：
+// 过期消息之前已没有其他消息时推进到最大过期 seq 之后，否则停在最早的剩余消息
+func (s *MessageService) deleteExpiredMsgs(ctx context.Context, conversationID string, msgs []*model.Message) (int64, error) {
+	ids := make([]int64, 0, len(msgs))
+	var maxSeq int64
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+		if msg.Seq > maxSeq {
+			maxSeq = msg.Seq
+		}
+	}
+	var minSeq int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteMsgsTx(tx, ids); err != nil {
+			return err
+		}
+		var remain []int64
+		if err := tx.Model(&model.Message{}).
+			Where("conversation_id = ? AND seq <= ?", conversationID, maxSeq).
+			Order("seq").Limit(1).
+			Pluck("seq", &remain).Error; err != nil {
+			return err
+		}
+		if len(remain) > 0 {
+			minSeq = remain[0]
+		} else {
+			minSeq = maxSeq + 1
+		}
+		return nil
+	})
+	return minSeq, err
+}
+
+// deleteMsgsTx 删除消息及@、搜索索引、表情回应等关联数据
+func deleteMsgsTx(tx *gorm.DB, ids []int64) error {
+	for _, m := range []interface{}{&model.MsgAt{}, &model.MsgSearchIndex{}, &model.MsgReaction{}, &model.MsgReactionCount{}, &model.MsgPin{}, &model.UserTimeline{}} {
+		if err := tx.Where("msg_id IN ?", ids).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", ids).Delete(&model.Message{}).Error
+}
+
+func (s *MessageService) pushMsgExpired(ctx context.Context, conversationID string, seqs []int64, minSeq int64) {
+	userIDs, err := s.getConversationMemberIDs(ctx, conversationID)
+	if err != nil {
+		log.Printf("pushMsgExpired get members error: %v, conversationID: %v", err, conversationID)
+		return
+	}
+	if err := notify.Push(ctx, constant.MsgTypeMsgExpired, userIDs, conversationID, MsgExpiredNotify{
+		ConversationID: conversationID,
+		Seqs:           seqs,
+		MinSeq:         minSeq,
+	}); err != nil {
+		log.Printf("pushMsgExpired push error: %v, conversationID: %v", err, conversationID)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMsgTTLAndExpire(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Message{}, &model.ConversationSetting{}, &model.GroupMember{},
+		&model.MsgAt{}, &model.MsgSearchIndex{}, &model.MsgReaction{}, &model.MsgReactionCount{}, &model.MsgPin{}, &model.UserTimeline{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	convID := GetConversationID(constant.SingleChatType, 1, 2)
+
+	// 会话默认 TTL
+	if err := s.SetConversationMsgTTL(ctx, SetConversationMsgTTLReq{UserID: 3, ConversationID: convID, TTL: 60}); err == nil {
+		t.Fatal("expected error for non member")
+	}
+	if err := s.SetConversationMsgTTL(ctx, SetConversationMsgTTLReq{UserID: 1, ConversationID: convID, TTL: 60}); err != nil {
+		t.Fatalf("SetConversationMsgTTL: %v", err)
+	}
+	req := &SendMessageReq{SenderID: 2, ConvType: constant.SingleChatType, TargetID: 1}
+	if err := s.fillMsgTTL(ctx, req); err != nil || req.TTL != 60 {
+		t.Fatalf("fillMsgTTL = %v, ttl %d, want 60", err, req.TTL)
+	}
+	req = &SendMessageReq{SenderID: 1, ConvType: constant.GroupChatType, TargetID: 9, BurnAfterRead: true}
+	if err := s.fillMsgTTL(ctx, req); err == nil {
+		t.Fatal("expected error for burn after read in group")
+	}
+
+	// 阅后即焚：只有对方已读才开始计时
+	msgs := []*model.Message{
+		{ID: 11, ConversationID: convID, Seq: 1, SenderID: 1, TTL: 10, BurnAfterRead: true},
+		{ID: 12, ConversationID: convID, Seq: 2, SenderID: 2, TTL: 10, BurnAfterRead: true},
+		{ID: 13, ConversationID: convID, Seq: 3, SenderID: 1},
+	}
+	if err := db.Create(msgs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.startBurnAfterRead(ctx, convID, 2, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	var expireAts []int64
+	db.Model(&model.Message{}).Order("seq").Pluck("expire_at", &expireAts)
+	if expireAts[0] == 0 || expireAts[1] != 0 || expireAts[2] != 0 {
+		t.Fatalf("expire_at = %v, want only seq 1 started", expireAts)
+	}
+
+	// 最早的消息过期后 MinSeq 推进到下一条，关联数据一并删除
+	db.Create(&model.MsgReaction{MsgID: 11, UserID: 2, Emoji: "👍", ConversationID: convID})
+	minSeq, err := s.deleteExpiredMsgs(ctx, convID, msgs[:1])
+	if err != nil || minSeq != 2 {
+		t.Fatalf("deleteExpiredMsgs = %d, %v, want 2", minSeq, err)
+	}
+	var count int64
+	db.Model(&model.MsgReaction{}).Where("msg_id = ?", 11).Count(&count)
+	if count != 0 {
+		t.Fatalf("reactions not deleted: %d", count)
+	}
+	// 中间的消息过期不推进 MinSeq
+	minSeq, err = s.deleteExpiredMsgs(ctx, convID, []*model.Message{{ID: 13, Seq: 3}})
+	if err != nil || minSeq != 2 {
+		t.Fatalf("deleteExpiredMsgs = %d, %v, want 2", minSeq, err)
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	ForwardModeSingle = 1 // 逐条转发
+	ForwardModeMerge  = 2 // 合并为一条聊天记录
+	// 单次转发最多的目标会话数
+	maxForwardTargets = 20
+)
+
+type ForwardTarget struct {
+	ConvType int32 `json:"conv_type"`
+	TargetID int64 `json:"target_id,string"`
+}
+
+type ForwardMsgReq struct {
+	UserID         int64            `json:"user_id,string"`
+	ConversationID string           `json:"conversation_id" binding:"required"` // 源会话
+	Seqs           []int64          `json:"seqs" binding:"required"`
+	Mode           int32            `json:"mode" binding:"required"`
+	Targets        []*ForwardTarget `json:"targets" binding:"required"`
+	Title          string           `json:"title"` // 合并转发的标题，如"张三和李四的聊天记录"
+	ClientMsgID    string           `json:"client_msg_id"`
+}
+
+// ForwardResult 单个目标会话的转发结果
+type ForwardResult struct {
+	ConvType     int32   `json:"conv_type"`
+	TargetID     int64   `json:"target_id,string"`
+	ServerMsgIDs []int64 `json:"server_msg_ids"`
+	ErrCode      int     `json:"err_code"`
+	ErrMsg       string  `json:"err_msg,omitempty"`
+}
+
+type ForwardMsgResp struct {
+	Results []*ForwardResult `json:"results"`
+}
+
+// ForwardMsg 把源会话中的消息转发到多个会话。
+// 逐条转发时按 seq 顺序原样重发；合并转发时生成一条内嵌原消息副本的聊天记录。
+// 单个目标失败不影响其他目标。
+func (p *MsgProducer) ForwardMsg(ctx context.Context, req ForwardMsgReq) (*ForwardMsgResp, error) {
+	if req.Mode != ForwardModeSingle && req.Mode != ForwardModeMerge {
+		return nil, errs.ErrInvalidParam.WithDetail("invalid forward mode")
+	}
+	if len(req.Targets) == 0 || len(req.Targets) > maxForwardTargets {
+		return nil, errs.ErrInvalidParam.WithDetail(fmt.Sprintf("目标会话数应在 1 到 %d 之间", maxForwardTargets))
+	}
+	if len(req.Seqs) == 0 || len(req.Seqs) > msgcontent.MaxMergeMsgNum {
+		return nil, errs.ErrInvalidParam.WithDetail(fmt.Sprintf("转发消息数应在 1 到 %d 之间", msgcontent.MaxMergeMsgNum))
+	}
+	msgs, err := p.messageService.getForwardSourceMsgs(ctx, req.UserID, req.ConversationID, req.Seqs)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := buildForwardContents(req, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ForwardMsgResp{Results: make([]*ForwardResult, 0, len(req.Targets))}
+	for i, target := range req.Targets {
+		if target == nil {
+			continue
+		}
+		result := &ForwardResult{ConvType: target.ConvType, TargetID: target.TargetID}
+		resp.Results = append(resp.Results, result)
+		if err := p.messageService.checkForwardTarget(ctx, req.UserID, target); err != nil {
+			setForwardErr(result, err)
+			continue
+		}
+		for j, content := range contents {
+			sendReq := &SendMessageReq{
+				SenderID:    req.UserID,
+				ConvType:    target.ConvType,
+				TargetID:    target.TargetID,
+				MsgType:     content.MsgType,
+				Content:     content.Content,
+				forwardSend: true,
+			}
+			if req.ClientMsgID != "" {
+				sendReq.ClientMsgID = fmt.Sprintf("%s-%d-%d", req.ClientMsgID, i, j)
+			}
+			sendResp, err := p.Send(ctx, sendReq)
+			if err != nil {
+				setForwardErr(result, err)
+				break
+			}
+			result.ServerMsgIDs = append(result.ServerMsgIDs, sendResp.ServerMsgID)
+		}
+	}
+	return resp, nil
+}
+
+func setForwardErr(result *ForwardResult, err error) {
+	codeErr := toCodeError(err)
+	result.ErrCode, result.ErrMsg = codeErr.Code, codeErr.Msg
+}
+
+// getForwardSourceMsgs 校验转发者能读到源消息，按 seq 升序返回
+func (s *MessageService) getForwardSourceMsgs(ctx context.Context, userID int64, conversationID string, seqs []int64) ([]*model.Message, error) {
+	if err := s.checkConversationMember(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+	seqs = uniqueIDs(seqs)
+	if len(seqs) == 0 {
+		return nil, errs.ErrInvalidParam.WithDetail("seqs is empty")
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	minSeq, err := s.seqUserCache.GetSeqUserMinSeq(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if seqs[0] < minSeq {
+		return nil, errs.ErrMsgNotFound.WithDetail("消息已被清空")
+	}
+	msgs, err := s.GetMessageBySeqs(ctx, conversationID, userID, seqs)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != len(seqs) {
+		return nil, errs.ErrMsgNotFound
+	}
+	if err := checkForwardable(msgs, time.Now().UnixMilli()); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// checkForwardable 只有普通内容消息可以转发，撤回、阅后即焚和已过期的消息不能转发
+func checkForwardable(msgs []*model.Message, now int64) error {
+	for _, msg := range msgs {
+		if msg.ID == 0 {
+			return errs.ErrMsgNotFound
+		}
+		if msg.MsgType >= constant.MsgTypeRevoke {
+			return errs.ErrInvalidParam.WithDetail("该类型消息不能转发")
+		}
+		if msg.Status != 0 || msg.BurnAfterRead || (msg.ExpireAt > 0 && msg.ExpireAt <= now) {
+			return errs.ErrInvalidParam.WithDetail(fmt.Sprintf("消息 %d 不能转发", msg.Seq))
+		}
+	}
+	return nil
+}
+
+// buildForwardContents 生成要发往每个目标会话的消息内容
+func buildForwardContents(req ForwardMsgReq, msgs []*model.Message) ([]SendMessageReq, error) {
+	if req.Mode == ForwardModeSingle {
+		contents := make([]SendMessageReq, 0, len(msgs))
+		for _, msg := range msgs {
+			contents = append(contents, SendMessageReq{MsgType: msg.MsgType, Content: msg.Content})
+		}
+		return contents, nil
+	}
+	elem := msgcontent.MergeElem{Title: req.Title, Messages: make([]*msgcontent.MergedMsg, 0, len(msgs))}
+	for _, msg := range msgs {
+		elem.Messages = append(elem.Messages, &msgcontent.MergedMsg{
+			MsgID:          msg.ID,
+			ConversationID: msg.ConversationID,
+			SenderID:       msg.SenderID,
+			MsgType:        msg.MsgType,
+			Content:        msg.Content,
+			SendTime:       msg.SendTime,
+		})
+	}
+	content, err := json.Marshal(elem)
+	if err != nil {
+		return nil, err
+	}
+	return []SendMessageReq{{MsgType: constant.MsgTypeMerge, Content: string(content)}}, nil
+}
+
+// checkForwardTarget 校验目标会话可达：单聊对方存在，群聊转发者是群成员
+func (s *MessageService) checkForwardTarget(ctx context.Context, userID int64, target *ForwardTarget) error {
+	switch target.ConvType {
+	case constant.SingleChatType:
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&model.User{}).
+			Where("user_id = ?", target.TargetID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errs.ErrUserNotFound
+		}
+		return nil
+	case constant.GroupChatType:
+		return s.checkConversationMember(ctx, userID, GetConversationID(target.ConvType, userID, target.TargetID))
+	}
+	return errs.ErrInvalidParam.WithDetail("invalid conv type")
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCheckForwardable(t *testing.T) {
+	now := int64(1000)
+	ok := &model.Message{ID: 1, Seq: 1, MsgType: constant.MsgTypeText}
+	cases := []struct {
+		name string
+		msg  *model.Message
+		ok   bool
+	}{
+		{"text", ok, true},
+		{"revoked", &model.Message{ID: 2, MsgType: constant.MsgTypeText, Status: 1}, false},
+		{"burn after read", &model.Message{ID: 3, MsgType: constant.MsgTypeText, BurnAfterRead: true}, false},
+		{"expired", &model.Message{ID: 4, MsgType: constant.MsgTypeText, ExpireAt: now}, false},
+		{"group event", &model.Message{ID: 5, MsgType: constant.MsgTypeMemberJoin}, false},
+	}
+	for _, c := range cases {
+		if err := checkForwardable([]*model.Message{c.msg}, now); (err == nil) != c.ok {
+			t.Fatalf("%s: err = %v", c.name, err)
+		}
+	}
+}
+
+func TestBuildForwardContents(t *testing.T) {
+	msgs := []*model.Message{
+		{ID: 1, ConversationID: "single:1_2", Seq: 1, SenderID: 1, MsgType: constant.MsgTypeText, Content: `{"content":"a"}`},
+		{ID: 2, ConversationID: "single:1_2", Seq: 2, SenderID: 2, MsgType: constant.MsgTypeImage, Content: `{"url":"http://x/1.png"}`},
+	}
+	contents, err := buildForwardContents(ForwardMsgReq{Mode: ForwardModeSingle}, msgs)
+	if err != nil || len(contents) != 2 || contents[1].MsgType != constant.MsgTypeImage {
+		t.Fatalf("single contents = %+v, %v", contents, err)
+	}
+	contents, err = buildForwardContents(ForwardMsgReq{Mode: ForwardModeMerge, Title: "聊天记录"}, msgs)
+	if err != nil || len(contents) != 1 || contents[0].MsgType != constant.MsgTypeMerge {
+		t.Fatalf("merge contents = %+v, %v", contents, err)
+	}
+	if err := msgcontent.Validate(constant.MsgTypeMerge, contents[0].Content); err != nil {
+		t.Fatalf("merge content invalid: %v", err)
+	}
+	elem, err := msgcontent.Decode[msgcontent.MergeElem](contents[0].Content)
+	if err != nil || len(elem.Messages) != 2 || elem.Messages[1].SenderID != 2 {
+		t.Fatalf("merge elem = %+v, %v", elem, err)
+	}
+}
+
+func TestCheckForwardTarget(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.GroupMember{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	db.Create(&model.User{UserID: 2, Username: "u2", PasswordHash: "x"})
+	db.Create(&model.GroupMember{GroupID: "9", UserID: 1, RoleLevel: roleMember})
+
+	if err := s.checkForwardTarget(ctx, 1, &ForwardTarget{ConvType: constant.SingleChatType, TargetID: 2}); err != nil {
+		t.Fatalf("single target err = %v", err)
+	}
+	if err := s.checkForwardTarget(ctx, 1, &ForwardTarget{ConvType: constant.SingleChatType, TargetID: 3}); !errors.Is(err, errs.ErrUserNotFound) {
+		t.Fatalf("missing user err = %v", err)
+	}
+	if err := s.checkForwardTarget(ctx, 1, &ForwardTarget{ConvType: constant.GroupChatType, TargetID: 9}); err != nil {
+		t.Fatalf("group target err = %v", err)
+	}
+	if err := s.checkForwardTarget(ctx, 2, &ForwardTarget{ConvType: constant.GroupChatType, TargetID: 9}); !errors.Is(err, errs.ErrNotConversationMember) {
+		t.Fatalf("non member err = %v", err)
+	}
+	if err := s.checkForwardTarget(ctx, 1, &ForwardTarget{ConvType: constant.NotificationChatType, TargetID: 1}); err == nil {
+		t.Fatalf("notification target should fail")
+	}
+}
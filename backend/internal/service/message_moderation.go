@@ -0,0 +1,84 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/moderation"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"log"
+	"strings"
+)
+
+// moderator 发送前的内容审核器，未初始化时不审核
+var moderator moderation.Moderator
+
+// InitModerator 设置内容审核器，外部分类服务可与敏感词过滤组成 moderation.Chain
+func InitModerator(m moderation.Moderator) {
+	moderator = m
+}
+
+// ModerateSendMessage 在投递 Kafka 前审核消息内容。
+// 拦截时返回 ErrMsgBlocked；替换时直接修改 req.Content；
+// 有命中时返回待保存的审核记录，由调用方在消息投递后补上 ServerMsgID。
+// 审核器出错时放行，只记录日志。
+func (s *MessageService) ModerateSendMessage(ctx context.Context, req *SendMessageReq) (*model.MsgModerationRecord, error) {
+	// 系统通知、群事件等服务端产生的消息不审核
+	if moderator == nil || req.serverSend {
+		return nil, nil
+	}
+	res, err := moderator.Moderate(ctx, req.MsgType, req.Content)
+	if err != nil {
+		log.Printf("ModerateSendMessage error: %v, senderID: %d", err, req.SenderID)
+		return nil, nil
+	}
+	if res == nil || res.Action == moderation.ActionPass {
+		return nil, nil
+	}
+	record := &model.MsgModerationRecord{
+		ConversationID: GetConversationID(req.ConvType, req.SenderID, req.TargetID),
+		SenderID:       req.SenderID,
+		MsgType:        req.MsgType,
+		Action:         int32(res.Action),
+		Source:         res.Source,
+		Words:          joinModerationWords(res.Words),
+		Content:        req.Content,
+	}
+	switch res.Action {
+	case moderation.ActionBlock:
+		go s.saveModerationRecord(context.Background(), record)
+		return nil, errs.ErrMsgBlocked
+	case moderation.ActionMask:
+		// 替换后的内容重新校验，审核器不应产生非法内容
+		if err := msgcontent.Validate(req.MsgType, res.Content); err != nil {
+			log.Printf("ModerateSendMessage masked content invalid: %v, senderID: %d", err, req.SenderID)
+			return nil, errs.ErrMsgBlocked
+		}
+		req.Content = res.Content
+	}
+	return record, nil
+}
+
+func (s *MessageService) saveModerationRecord(ctx context.Context, record *model.MsgModerationRecord) {
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		log.Printf("saveModerationRecord error: %v, senderID: %d", err, record.SenderID)
+	}
+}
+
+// joinModerationWords 去重后拼接命中词，超出字段长度时截断
+func joinModerationWords(words []string) string {
+	seen := make(map[string]struct{}, len(words))
+	list := make([]string, 0, len(words))
+	for _, w := range words {
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		list = append(list, w)
+	}
+	joined := strings.Join(list, ",")
+	if runes := []rune(joined); len(runes) > 128 {
+		joined = string(runes[:128])
+	}
+	return joined
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/moderation"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestModerateSendMessage(t *testing.T) {
+	filter, err := moderation.NewWordFilter("", moderation.ActionMask)
+	if err != nil {
+		t.Fatalf("NewWordFilter error: %v", err)
+	}
+	filter.SetWords(map[string]moderation.Action{"bad": moderation.ActionMask, "spam": moderation.ActionBlock, "review": moderation.ActionFlag})
+	old := moderator
+	defer func() { moderator = old }()
+	InitModerator(moderation.Chain{filter})
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.MsgModerationRecord{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	newReq := func(content string) *SendMessageReq {
+		return &SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeText, Content: content}
+	}
+
+	req := newReq(`{"content":"hello"}`)
+	if record, err := s.ModerateSendMessage(ctx, req); err != nil || record != nil {
+		t.Fatalf("pass = %+v, %v", record, err)
+	}
+	req = newReq(`{"content":"a bad word"}`)
+	record, err := s.ModerateSendMessage(ctx, req)
+	if err != nil || record == nil || record.Action != int32(moderation.ActionMask) {
+		t.Fatalf("mask = %+v, %v", record, err)
+	}
+	if req.Content != `{"content":"a *** word"}` || record.Content != `{"content":"a bad word"}` || record.ConversationID != "single:1_2" {
+		t.Fatalf("masked content = %s, record = %+v", req.Content, record)
+	}
+	req = newReq(`{"content":"please review"}`)
+	if record, err := s.ModerateSendMessage(ctx, req); err != nil || record == nil || record.Action != int32(moderation.ActionFlag) || req.Content != `{"content":"please review"}` {
+		t.Fatalf("flag = %+v, %v", record, err)
+	}
+	// 服务端产生的系统消息不审核
+	req = newReq(`{"group_id":"1","operator_user_id":"1","group_name":"spam"}`)
+	req.MsgType, req.serverSend = constant.MsgTypeGroupCreated, true
+	if record, err := s.ModerateSendMessage(ctx, req); err != nil || record != nil {
+		t.Fatalf("system msg = %+v, %v", record, err)
+	}
+	// 拦截记录异步写库，这里只校验返回的错误
+	InitModerator(moderation.Chain{filter, blockAll{}})
+	req = newReq(`{"content":"hello"}`)
+	if _, err := s.ModerateSendMessage(ctx, req); !errors.Is(err, errs.ErrMsgBlocked) {
+		t.Fatalf("block err = %v", err)
+	}
+}
+
+type blockAll struct{}
+
+func (blockAll) Name() string { return "block_all" }
+
+func (blockAll) Moderate(ctx context.Context, msgType int32, content string) (*moderation.Result, error) {
+	return &moderation.Result{Action: moderation.ActionBlock, Content: content}, nil
+}
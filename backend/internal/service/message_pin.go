@@ -0,0 +1,216 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"backend/internal/pkg/notify"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PinMsgReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	Seq            int64  `json:"seq" binding:"required"`
+}
+
+type GetPinnedMsgsReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+}
+
+type PinnedMsg struct {
+	*model.MsgPin
+	Msg *model.Message `json:"msg"` // 消息已被清理时为空
+}
+
+type GetPinnedMsgsResp struct {
+	Pins []*PinnedMsg `json:"pins"`
+}
+
+// PinUpdateNotify 推送给会话成员的置顶变化
+type PinUpdateNotify struct {
+	ConversationID string `json:"conversation_id"`
+	MsgID          int64  `json:"msg_id,string"`
+	Seq            int64  `json:"seq"`
+	OperatorID     int64  `json:"operator_id,string"`
+	Pinned         bool   `json:"pinned"` // true=置顶, false=取消置顶
+}
+
+// PinMsg 置顶消息，群聊要求群主或管理员，单聊双方均可。重复置顶是幂等的
+func (s *MessageService) PinMsg(ctx context.Context, req PinMsgReq) error {
+	msg, err := s.getPinTargetMsg(ctx, req)
+	if err != nil {
+		return err
+	}
+	pinned, err := s.savePin(ctx, msg, req.UserID)
+	if err != nil || !pinned {
+		return err
+	}
+	s.afterPinUpdate(ctx, msg, req.UserID, true)
+	return nil
+}
+
+// UnpinMsg 取消置顶，权限与置顶相同，未置顶时直接返回
+func (s *MessageService) UnpinMsg(ctx context.Context, req PinMsgReq) error {
+	msg, err := s.getPinTargetMsg(ctx, req)
+	if err != nil {
+		return err
+	}
+	res := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND msg_id = ?", msg.ConversationID, msg.ID).
+		Delete(&model.MsgPin{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		s.afterPinUpdate(ctx, msg, req.UserID, false)
+	}
+	return nil
+}
+
+// GetPinnedMsgs 返回会话的置顶消息，最近置顶的在前。
+// 与拉取消息一样，会话或用户 MinSeq 之前的消息和已过期的消息不返回
+func (s *MessageService) GetPinnedMsgs(ctx context.Context, req GetPinnedMsgsReq) (GetPinnedMsgsResp, error) {
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return GetPinnedMsgsResp{}, err
+	}
+	visible, err := s.visibleScope(ctx, req.UserID, []string{req.ConversationID})
+	if err != nil {
+		return GetPinnedMsgsResp{}, err
+	}
+	var pins []*model.MsgPin
+	if err := s.db.WithContext(ctx).Where("conversation_id = ?", req.ConversationID).
+		Scopes(visible("msg_pins")).
+		Order("create_time DESC").
+		Find(&pins).Error; err != nil {
+		return GetPinnedMsgsResp{}, err
+	}
+	resp := GetPinnedMsgsResp{Pins: make([]*PinnedMsg, 0, len(pins))}
+	if len(pins) == 0 {
+		return resp, nil
+	}
+	msgIDs := make([]int64, 0, len(pins))
+	for _, pin := range pins {
+		msgIDs = append(msgIDs, pin.MsgID)
+	}
+	var msgs []*model.Message
+	if err := s.db.WithContext(ctx).Where("id IN ?", msgIDs).Find(&msgs).Error; err != nil {
+		return GetPinnedMsgsResp{}, err
+	}
+	msgMap := make(map[int64]*model.Message, len(msgs))
+	for _, msg := range msgs {
+		msgMap[msg.ID] = msg
+	}
+	now := time.Now().UnixMilli()
+	for _, pin := range pins {
+		msg := msgMap[pin.MsgID]
+		if msg != nil && msg.ExpireAt > 0 && msg.ExpireAt <= now {
+			continue
+		}
+		resp.Pins = append(resp.Pins, &PinnedMsg{MsgPin: pin, Msg: msg})
+	}
+	return resp, nil
+}
+
+// getPinTargetMsg 校验置顶权限并按 seq 取出目标消息
+func (s *MessageService) getPinTargetMsg(ctx context.Context, req PinMsgReq) (*model.Message, error) {
+	switch GetConvTypeFromConversationID(req.ConversationID) {
+	case constant.SingleChatType:
+		if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+			return nil, err
+		}
+	case constant.GroupChatType:
+		groupID, _ := GetGroupIDFromConversationID(req.ConversationID)
+		if err := s.checkGroupAdmin(ctx, groupID, req.UserID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errs.ErrInvalidParam.WithDetail("该会话不支持置顶消息")
+	}
+	msgs, err := s.GetMessageBySeqs(ctx, req.ConversationID, req.UserID, []int64{req.Seq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0].ID == 0 {
+		return nil, errs.ErrMsgNotFound
+	}
+	return msgs[0], nil
+}
+
+// savePin 写入置顶记录，已置顶时返回 false
+func (s *MessageService) savePin(ctx context.Context, msg *model.Message, userID int64) (bool, error) {
+	var pinned bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住会话的 seq 行，同一会话的置顶串行执行，避免并发时超出数量上限
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", msg.ConversationID).
+			Find(&model.SeqConversation{}).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.MsgPin{}).
+			Where("conversation_id = ? AND msg_id <> ?", msg.ConversationID, msg.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(conf.MsgPinMaxCount) {
+			return errs.ErrMsgPinLimit
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.MsgPin{
+			ConversationID: msg.ConversationID,
+			MsgID:          msg.ID,
+			Seq:            msg.Seq,
+			PinnedBy:       userID,
+		})
+		pinned = res.RowsAffected > 0
+		return res.Error
+	})
+	return pinned, err
+}
+
+// afterPinUpdate 推送置顶变化，群聊同时在会话中生成一条群事件消息
+func (s *MessageService) afterPinUpdate(ctx context.Context, msg *model.Message, operatorID int64, pinned bool) {
+	go s.pushPinUpdate(context.Background(), msg, operatorID, pinned)
+	groupID, ok := GetGroupIDFromConversationID(msg.ConversationID)
+	if !ok {
+		return
+	}
+	if pinned {
+		sendGroupEvent(ctx, operatorID, groupID, constant.MsgTypeMsgPinned, &msgcontent.MsgPinnedElem{
+			GroupEventBase: groupEventBase(groupID, operatorID),
+			MsgID:          msg.ID,
+			Seq:            msg.Seq,
+			MsgAbstract:    msgSnapshot(msg.MsgType, msg.Content),
+		})
+		return
+	}
+	sendGroupEvent(ctx, operatorID, groupID, constant.MsgTypeMsgUnpinned, &msgcontent.MsgUnpinnedElem{
+		GroupEventBase: groupEventBase(groupID, operatorID),
+		MsgID:          msg.ID,
+		Seq:            msg.Seq,
+	})
+}
+
+func (s *MessageService) pushPinUpdate(ctx context.Context, msg *model.Message, operatorID int64, pinned bool) {
+	userIDs, err := s.getConversationMemberIDs(ctx, msg.ConversationID)
+	if err != nil {
+		log.Printf("pushPinUpdate get members error: %v, conversationID: %v", err, msg.ConversationID)
+		return
+	}
+	if err := notify.Push(ctx, constant.MsgTypePinUpdate, userIDs, msg.ConversationID, PinUpdateNotify{
+		ConversationID: msg.ConversationID,
+		MsgID:          msg.ID,
+		Seq:            msg.Seq,
+		OperatorID:     operatorID,
+		Pinned:         pinned,
+	}); err != nil {
+		log.Printf("pushPinUpdate push error: %v, conversationID: %v", err, msg.ConversationID)
+	}
+}
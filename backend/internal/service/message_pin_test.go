@@ -0,0 +1,77 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMsgPin(t *testing.T) {
+	old := conf
+	defer func() { conf = old }()
+	conf.MsgPinMaxCount = 2
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Message{}, &model.MsgPin{}, &model.SeqConversation{}, &model.SeqUser{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	convID := "single:1_2"
+	msgs := []*model.Message{
+		{ID: 1, ConversationID: convID, Seq: 1, SenderID: 1, MsgType: 101, Content: `{"content":"a"}`},
+		{ID: 2, ConversationID: convID, Seq: 2, SenderID: 2, MsgType: 101, Content: `{"content":"b"}`},
+		{ID: 3, ConversationID: convID, Seq: 3, SenderID: 1, MsgType: 101, Content: `{"content":"c"}`},
+	}
+	db.Create(msgs)
+
+	if pinned, err := s.savePin(ctx, msgs[0], 1); err != nil || !pinned {
+		t.Fatalf("savePin = %v, %v", pinned, err)
+	}
+	// 重复置顶幂等
+	if pinned, err := s.savePin(ctx, msgs[0], 2); err != nil || pinned {
+		t.Fatalf("savePin again = %v, %v", pinned, err)
+	}
+	if pinned, err := s.savePin(ctx, msgs[1], 2); err != nil || !pinned {
+		t.Fatalf("savePin = %v, %v", pinned, err)
+	}
+	if _, err := s.savePin(ctx, msgs[2], 1); !errors.Is(err, errs.ErrMsgPinLimit) {
+		t.Fatalf("savePin over limit err = %v", err)
+	}
+
+	resp, err := s.GetPinnedMsgs(ctx, GetPinnedMsgsReq{UserID: 2, ConversationID: convID})
+	if err != nil {
+		t.Fatalf("GetPinnedMsgs error: %v", err)
+	}
+	if len(resp.Pins) != 2 {
+		t.Fatalf("pins = %d, want 2", len(resp.Pins))
+	}
+	for _, pin := range resp.Pins {
+		if pin.Msg == nil || pin.Msg.ID != pin.MsgID {
+			t.Fatalf("pin msg = %+v", pin)
+		}
+	}
+	// 用户清空位点之前的和已过期的置顶消息不返回
+	db.Create(&model.SeqUser{UserID: 2, ConversationID: convID, MinSeq: 2})
+	db.Model(&model.Message{}).Where("id = ?", 2).Update("expire_at", 1)
+	if resp, err = s.GetPinnedMsgs(ctx, GetPinnedMsgsReq{UserID: 2, ConversationID: convID}); err != nil || len(resp.Pins) != 0 {
+		t.Fatalf("pins = %+v, %v", resp.Pins, err)
+	}
+	if resp, err = s.GetPinnedMsgs(ctx, GetPinnedMsgsReq{UserID: 1, ConversationID: convID}); err != nil || len(resp.Pins) != 1 || resp.Pins[0].MsgID != 1 {
+		t.Fatalf("pins = %+v, %v", resp.Pins, err)
+	}
+	if _, err := s.GetPinnedMsgs(ctx, GetPinnedMsgsReq{UserID: 3, ConversationID: convID}); !errors.Is(err, errs.ErrNotConversationMember) {
+		t.Fatalf("non member err = %v", err)
+	}
+	if _, err := s.getPinTargetMsg(ctx, PinMsgReq{UserID: 1, ConversationID: "notification:1", Seq: 1}); err == nil {
+		t.Fatalf("notification conversation should not support pin")
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/notify"
+	"context"
+	"log"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 单条消息最多的表情种类
+	maxReactionEmojisPerMsg = 20
+	// 表情最大长度(字节)，与表字段一致
+	maxReactionEmojiLen = 32
+)
+
+type ReactMsgReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	Seq            int64  `json:"seq" binding:"required"`
+	Emoji          string `json:"emoji" binding:"required"`
+}
+
+// ReactionNotify 推送给会话在线成员的表情回应变化
+type ReactionNotify struct {
+	ConversationID string `json:"conversation_id"`
+	MsgID          int64  `json:"msg_id,string"`
+	Seq            int64  `json:"seq"`
+	UserID         int64  `json:"user_id,string"`
+	Emoji          string `json:"emoji"`
+	Add            bool   `json:"add"`   // true=添加, false=取消
+	Count          int64  `json:"count"` // 变化后该表情的总数
+}
+
+// AddMsgReaction 给消息添加表情回应，重复添加是幂等的。表情回应不占用会话 seq。
+func (s *MessageService) AddMsgReaction(ctx context.Context, req ReactMsgReq) error {
+	msg, err := s.getReactionTargetMsg(ctx, req)
+	if err != nil {
+		return err
+	}
+	var added bool
+	var count int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var emojiCount int64
+		if err := tx.Model(&model.MsgReactionCount{}).
+			Where("msg_id = ? AND emoji <> ?", msg.ID, req.Emoji).
+			Count(&emojiCount).Error; err != nil {
+			return err
+		}
+		if emojiCount >= maxReactionEmojisPerMsg {
+			return errs.ErrReactionLimit
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.MsgReaction{
+			MsgID:          msg.ID,
+			UserID:         req.UserID,
+			Emoji:          req.Emoji,
+			ConversationID: req.ConversationID,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		added = res.RowsAffected > 0
+		if added {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "msg_id"}, {Name: "emoji"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("count + 1")}),
+			}).Create(&model.MsgReactionCount{
+				MsgID:          msg.ID,
+				Emoji:          req.Emoji,
+				ConversationID: req.ConversationID,
+				Count:          1,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.MsgReactionCount{}).
+			Where("msg_id = ? AND emoji = ?", msg.ID, req.Emoji).
+			Pluck("count", &count).Error
+	}); err != nil {
+		return err
+	}
+	if added {
+		go s.pushReaction(context.Background(), msg, req, true, count)
+	}
+	return nil
+}
+
+// RemoveMsgReaction 取消表情回应，未回应过时直接返回
+func (s *MessageService) RemoveMsgReaction(ctx context.Context, req ReactMsgReq) error {
+	msg, err := s.getReactionTargetMsg(ctx, req)
+	if err != nil {
+		return err
+	}
+	var removed bool
+	var count int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("msg_id = ? AND user_id = ? AND emoji = ?", msg.ID, req.UserID, req.Emoji).
+			Delete(&model.MsgReaction{})
+		if res.Error != nil {
+			return res.Error
+		}
+		removed = res.RowsAffected > 0
+		if !removed {
+			return nil
+		}
+		if err := tx.Model(&model.MsgReactionCount{}).
+			Where("msg_id = ? AND emoji = ?", msg.ID, req.Emoji).
+			Update("count", gorm.Expr("count - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("msg_id = ? AND emoji = ? AND count <= 0", msg.ID, req.Emoji).
+			Delete(&model.MsgReactionCount{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.MsgReactionCount{}).
+			Where("msg_id = ? AND emoji = ?", msg.ID, req.Emoji).
+			Pluck("count", &count).Error
+	}); err != nil {
+		return err
+	}
+	if removed {
+		go s.pushReaction(context.Background(), msg, req, false, count)
+	}
+	return nil
+}
+
+// getReactionTargetMsg 校验会话成员身份并按 seq 取出目标消息（先查缓存，刚发出的消息也能回应）
+func (s *MessageService) getReactionTargetMsg(ctx context.Context, req ReactMsgReq) (*model.Message, error) {
+	if len(req.Emoji) > maxReactionEmojiLen {
+		return nil, errs.ErrInvalidParam.WithDetail("emoji too long")
+	}
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return nil, err
+	}
+	msgs, err := s.GetMessageBySeqs(ctx, req.ConversationID, req.UserID, []int64{req.Seq})
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 || msgs[0].ID == 0 {
+		return nil, errs.ErrMsgNotFound
+	}
+	return msgs[0], nil
+}
+
+func (s *MessageService) pushReaction(ctx context.Context, msg *model.Message, req ReactMsgReq, add bool, count int64) {
+	userIDs, err := s.getConversationMemberIDs(ctx, req.ConversationID)
+	if err != nil {
+		log.Printf("pushReaction get members error: %v, conversationID: %v", err, req.ConversationID)
+		return
+	}
+	if err := notify.Push(ctx, constant.MsgTypeReaction, userIDs, req.ConversationID, ReactionNotify{
+		ConversationID: req.ConversationID,
+		MsgID:          msg.ID,
+		Seq:            msg.Seq,
+		UserID:         req.UserID,
+		Emoji:          req.Emoji,
+		Add:            add,
+		Count:          count,
+	}); err != nil {
+		log.Printf("pushReaction push error: %v, conversationID: %v", err, req.ConversationID)
+	}
+}
+
+// fillMsgReactions 为拉取到的消息填充表情回应计数及当前用户的回应状态
+func (s *MessageService) fillMsgReactions(ctx context.Context, userID int64, msgs []*model.Message) error {
+	msgIDs := make([]int64, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.ID != 0 {
+			msgIDs = append(msgIDs, msg.ID)
+		}
+	}
+	if len(msgIDs) == 0 {
+		return nil
+	}
+	var counts []model.MsgReactionCount
+	if err := s.db.WithContext(ctx).Where("msg_id IN ? AND count > 0", msgIDs).Find(&counts).Error; err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	var mine []model.MsgReaction
+	if err := s.db.WithContext(ctx).Select("msg_id", "emoji").
+		Where("msg_id IN ? AND user_id = ?", msgIDs, userID).
+		Find(&mine).Error; err != nil {
+		return err
+	}
+	reactions := buildReactionSummaries(counts, mine)
+	for _, msg := range msgs {
+		msg.Reactions = reactions[msg.ID]
+	}
+	return nil
+}
+
+// buildReactionSummaries 按消息聚合表情回应，同一消息内按数量降序、表情升序
+func buildReactionSummaries(counts []model.MsgReactionCount, mine []model.MsgReaction) map[int64][]*model.MsgReactionSummary {
+	reacted := make(map[string]struct{}, len(mine))
+	for _, r := range mine {
+		reacted[strconv.FormatInt(r.MsgID, 10)+":"+r.Emoji] = struct{}{}
+	}
+	result := make(map[int64][]*model.MsgReactionSummary)
+	for _, c := range counts {
+		_, ok := reacted[strconv.FormatInt(c.MsgID, 10)+":"+c.Emoji]
+		result[c.MsgID] = append(result[c.MsgID], &model.MsgReactionSummary{
+			Emoji:   c.Emoji,
+			Count:   c.Count,
+			Reacted: ok,
+		})
+	}
+	for _, list := range result {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Emoji < list[j].Emoji
+		})
+	}
+	return result
+}
+
+// getConversationMemberIDs 返回会话的全部成员
+func (s *MessageService) getConversationMemberIDs(ctx context.Context, conversationID string) ([]int64, error) {
+	switch GetConvTypeFromConversationID(conversationID) {
+	case constant.SingleChatType:
+		a, b, ok := GetSingleChatUserIDs(conversationID)
+		if !ok {
+			return nil, errs.ErrInvalidParam.WithDetail("invalid conversation id")
+		}
+		return []int64{a, b}, nil
+	case constant.GroupChatType:
+		groupID, _ := GetGroupIDFromConversationID(conversationID)
+		var memberIDs []int64
+		if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).
+			Where("group_id = ?", groupID).
+			Pluck("user_id", &memberIDs).Error; err != nil {
+			return nil, err
+		}
+		return memberIDs, nil
+	case constant.NotificationChatType:
+		if userID, ok := GetNotificationUserIDFromConversationID(conversationID); ok {
+			return []int64{userID}, nil
+		}
+	}
+	return nil, errs.ErrInvalidParam.WithDetail("invalid conversation id")
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"backend/internal/model"
+	"testing"
+)
+
+func TestBuildReactionSummaries(t *testing.T) {
+	counts := []model.MsgReactionCount{
+		{MsgID: 1, Emoji: "👍", Count: 1},
+		{MsgID: 1, Emoji: "❤️", Count: 3},
+		{MsgID: 1, Emoji: "😂", Count: 1},
+		{MsgID: 2, Emoji: "👍", Count: 2},
+	}
+	mine := []model.MsgReaction{
+		{MsgID: 1, Emoji: "👍"},
+		{MsgID: 2, Emoji: "❤️"}, // 计数已被删除的脏数据不应产生结果
+	}
+	got := buildReactionSummaries(counts, mine)
+
+	if len(got[1]) != 3 {
+		t.Fatalf("msg 1 reactions = %d, want 3", len(got[1]))
+	}
+	if got[1][0].Emoji != "❤️" || got[1][0].Count != 3 || got[1][0].Reacted {
+		t.Fatalf("msg 1 first reaction = %+v", got[1][0])
+	}
+	for _, r := range got[1][1:] {
+		if r.Count != 1 {
+			t.Fatalf("unexpected order: %+v", r)
+		}
+		if r.Emoji == "👍" && !r.Reacted {
+			t.Fatalf("👍 should be marked as reacted")
+		}
+	}
+	if len(got[2]) != 1 || got[2][0].Reacted {
+		t.Fatalf("msg 2 reactions = %+v", got[2])
+	}
+}
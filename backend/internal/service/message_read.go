@@ -0,0 +1,268 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/cache/cachekey"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/notify"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 一次已读上报最多为最近多少条消息推送已读数变化，避免一次性已读大量消息时推送风暴
+const maxReadCountPushMsgs = 100
+
+type SetConversationHasReadSeqReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	HasReadSeq     int64  `json:"has_read_seq" binding:"required"`
+}
+
+// SetConversationHasReadSeq 上报会话已读位点，只前进不后退。
+// 群聊人数不超过配置上限时，会把受影响消息的已读数推送给各自的发送者。
+func (s *MessageService) SetConversationHasReadSeq(ctx context.Context, req SetConversationHasReadSeqReq) error {
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return err
+	}
+	maxSeq, err := s.seqConvCache.GetMaxSeq(ctx, req.ConversationID)
+	if err != nil {
+		return err
+	}
+	if req.HasReadSeq > maxSeq {
+		req.HasReadSeq = maxSeq
+	}
+	var oldReadSeq int64
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var conv model.Conversation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(model.Conversation{OwnerID: req.UserID, ConversationID: req.ConversationID}).
+			Attrs(model.Conversation{ConvType: GetConvTypeFromConversationID(req.ConversationID)}).
+			FirstOrCreate(&conv).Error; err != nil {
+			return err
+		}
+		oldReadSeq = conv.ReadSeq
+		if req.HasReadSeq <= oldReadSeq {
+			return nil
+		}
+		return tx.Model(&model.Conversation{}).
+			Where("owner_id = ? AND conversation_id = ?", req.UserID, req.ConversationID).
+			Update("read_seq", req.HasReadSeq).Error
+	}); err != nil {
+		return err
+	}
+	if req.HasReadSeq <= oldReadSeq {
+		return nil
+	}
+	if err := redis.GetRDB().Del(ctx, cachekey.GetConversationKey(strconv.FormatInt(req.UserID, 10), req.ConversationID)).Err(); err != nil {
+		log.Printf("SetConversationHasReadSeq del conversation cache error: %v", err)
+	}
+	if GetConvTypeFromConversationID(req.ConversationID) == constant.SingleChatType {
+		if err := s.startBurnAfterRead(ctx, req.ConversationID, req.UserID, oldReadSeq, req.HasReadSeq); err != nil {
+			log.Printf("SetConversationHasReadSeq start burn after read error: %v", err)
+		}
+	}
+	if groupID, ok := GetGroupIDFromConversationID(req.ConversationID); ok {
+		go s.pushGroupReadCounts(context.Background(), groupID, req.ConversationID, req.UserID, oldReadSeq, req.HasReadSeq)
+	}
+	return nil
+}
+
+type GroupMsgReadCount struct {
+	Seq         int64 `json:"seq"`
+	ReadCount   int   `json:"read_count"`
+	UnreadCount int   `json:"unread_count"`
+}
+
+// GroupReadCountNotify 推送给消息发送者的已读数变化
+type GroupReadCountNotify struct {
+	ConversationID string               `json:"conversation_id"`
+	ReaderID       int64                `json:"reader_id,string"`
+	Counts         []*GroupMsgReadCount `json:"counts"`
+}
+
+func (s *MessageService) pushGroupReadCounts(ctx context.Context, groupID, conversationID string, readerID, oldReadSeq, newReadSeq int64) {
+	readSeqs, err := s.getGroupMemberReadSeqs(ctx, groupID, conversationID)
+	if err != nil {
+		log.Printf("pushGroupReadCounts get member read seqs error: %v, conversationID: %v", err, conversationID)
+		return
+	}
+	if len(readSeqs) > conf.GroupReadReceiptMaxMembers {
+		return
+	}
+	begin := oldReadSeq + 1
+	if newReadSeq-begin+1 > maxReadCountPushMsgs {
+		begin = newReadSeq - maxReadCountPushMsgs + 1
+	}
+	seqs := make([]int64, 0, newReadSeq-begin+1)
+	for seq := begin; seq <= newReadSeq; seq++ {
+		seqs = append(seqs, seq)
+	}
+	msgs, err := s.GetMessageBySeqs(ctx, conversationID, readerID, seqs)
+	if err != nil {
+		log.Printf("pushGroupReadCounts get messages error: %v, conversationID: %v", err, conversationID)
+		return
+	}
+	senderCounts := make(map[int64][]*GroupMsgReadCount)
+	for _, msg := range msgs {
+		if msg.ID == 0 || msg.SenderID == readerID {
+			continue
+		}
+		read, unread := countGroupMsgRead(readSeqs, msg.SenderID, msg.Seq)
+		senderCounts[msg.SenderID] = append(senderCounts[msg.SenderID], &GroupMsgReadCount{
+			Seq:         msg.Seq,
+			ReadCount:   len(read),
+			UnreadCount: len(unread),
+		})
+	}
+	for senderID, counts := range senderCounts {
+		if err := notify.Push(ctx, constant.MsgTypeReadReport, []int64{senderID}, conversationID, GroupReadCountNotify{
+			ConversationID: conversationID,
+			ReaderID:       readerID,
+			Counts:         counts,
+		}); err != nil {
+			log.Printf("pushGroupReadCounts push error: %v, senderID: %v", err, senderID)
+		}
+	}
+}
+
+type GetGroupMsgReadStateReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	Seq            int64  `json:"seq" binding:"required"`
+}
+
+type GetGroupMsgReadStateResp struct {
+	Seq           int64   `json:"seq"`
+	ReadCount     int     `json:"read_count"`
+	UnreadCount   int     `json:"unread_count"`
+	ReadUserIDs   []int64 `json:"read_user_ids"`
+	UnreadUserIDs []int64 `json:"unread_user_ids"`
+}
+
+// GetGroupMsgReadState 查询群消息的已读/未读成员列表
+func (s *MessageService) GetGroupMsgReadState(ctx context.Context, req GetGroupMsgReadStateReq) (GetGroupMsgReadStateResp, error) {
+	groupID, ok := GetGroupIDFromConversationID(req.ConversationID)
+	if !ok {
+		return GetGroupMsgReadStateResp{}, errs.ErrInvalidParam.WithDetail("不是群聊会话")
+	}
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return GetGroupMsgReadStateResp{}, err
+	}
+	readSeqs, err := s.getGroupMemberReadSeqs(ctx, groupID, req.ConversationID)
+	if err != nil {
+		return GetGroupMsgReadStateResp{}, err
+	}
+	if len(readSeqs) > conf.GroupReadReceiptMaxMembers {
+		return GetGroupMsgReadStateResp{}, errs.ErrGroupReadReceiptDisabled
+	}
+	msgs, err := s.GetMessageBySeqs(ctx, req.ConversationID, req.UserID, []int64{req.Seq})
+	if err != nil {
+		return GetGroupMsgReadStateResp{}, err
+	}
+	if len(msgs) == 0 || msgs[0].ID == 0 {
+		return GetGroupMsgReadStateResp{}, errs.ErrMsgNotFound
+	}
+	read, unread := countGroupMsgRead(readSeqs, msgs[0].SenderID, req.Seq)
+	return GetGroupMsgReadStateResp{
+		Seq:           req.Seq,
+		ReadCount:     len(read),
+		UnreadCount:   len(unread),
+		ReadUserIDs:   read,
+		UnreadUserIDs: unread,
+	}, nil
+}
+
+// getGroupMemberReadSeqs 返回群成员 -> 已读位点，没有会话记录的成员视为未读
+func (s *MessageService) getGroupMemberReadSeqs(ctx context.Context, groupID, conversationID string) (map[int64]int64, error) {
+	var memberIDs []int64
+	if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).Where("group_id = ?", groupID).Pluck("user_id", &memberIDs).Error; err != nil {
+		return nil, err
+	}
+	readSeqs := make(map[int64]int64, len(memberIDs))
+	for _, id := range memberIDs {
+		readSeqs[id] = 0
+	}
+	if len(memberIDs) == 0 || len(memberIDs) > conf.GroupReadReceiptMaxMembers {
+		return readSeqs, nil
+	}
+	var convs []model.Conversation
+	if err := s.db.WithContext(ctx).Select("owner_id", "read_seq").
+		Where("conversation_id = ? AND owner_id IN ?", conversationID, memberIDs).
+		Find(&convs).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range convs {
+		readSeqs[c.OwnerID] = c.ReadSeq
+	}
+	return readSeqs, nil
+}
+
+// countGroupMsgRead 根据成员已读位点计算某条消息的已读和未读成员，发送者本人不计入
+func countGroupMsgRead(readSeqs map[int64]int64, senderID, seq int64) (read []int64, unread []int64) {
+	read, unread = []int64{}, []int64{}
+	for userID, readSeq := range readSeqs {
+		if userID == senderID {
+			continue
+		}
+		if readSeq >= seq {
+			read = append(read, userID)
+		} else {
+			unread = append(unread, userID)
+		}
+	}
+	sort.Slice(read, func(i, j int) bool { return read[i] < read[j] })
+	sort.Slice(unread, func(i, j int) bool { return unread[i] < unread[j] })
+	return read, unread
+}
+
+// checkGroupAdmin 校验用户是群主或管理员
+func (s *MessageService) checkGroupAdmin(ctx context.Context, groupID string, userID int64) error {
+	var member model.GroupMember
+	if err := s.db.WithContext(ctx).Select("role_level").
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.ErrNotConversationMember
+		}
+		return err
+	}
+	if member.RoleLevel < roleAdmin {
+		return errs.ErrGroupPermissionDenied
+	}
+	return nil
+}
+
+// checkConversationMember 校验用户是否属于该会话
+func (s *MessageService) checkConversationMember(ctx context.Context, userID int64, conversationID string) error {
+	switch GetConvTypeFromConversationID(conversationID) {
+	case constant.SingleChatType:
+		a, b, ok := GetSingleChatUserIDs(conversationID)
+		if ok && (userID == a || userID == b) {
+			return nil
+		}
+	case constant.GroupChatType:
+		groupID, _ := GetGroupIDFromConversationID(conversationID)
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	case constant.NotificationChatType:
+		if ownerID, ok := GetNotificationUserIDFromConversationID(conversationID); ok && ownerID == userID {
+			return nil
+		}
+	}
+	return errs.ErrNotConversationMember
+}
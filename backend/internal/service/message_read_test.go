@@ -0,0 +1,46 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountGroupMsgRead(t *testing.T) {
+	readSeqs := map[int64]int64{
+		1: 10, // sender
+		2: 10,
+		3: 5,
+		4: 0,
+		5: 7,
+	}
+
+	read, unread := countGroupMsgRead(readSeqs, 1, 7)
+	if !reflect.DeepEqual(read, []int64{2, 5}) {
+		t.Errorf("expected read [2 5], got %v", read)
+	}
+	if !reflect.DeepEqual(unread, []int64{3, 4}) {
+		t.Errorf("expected unread [3 4], got %v", unread)
+	}
+
+	read, unread = countGroupMsgRead(readSeqs, 1, 11)
+	if len(read) != 0 || len(unread) != 4 {
+		t.Errorf("expected nobody read seq 11, got read=%v unread=%v", read, unread)
+	}
+}
+
+func TestConversationIDHelpers(t *testing.T) {
+	if got := GetConvTypeFromConversationID(GetConversationID(1, 7, 3)); got != 1 {
+		t.Errorf("expected single chat type, got %d", got)
+	}
+	a, b, ok := GetSingleChatUserIDs("single:3_7")
+	if !ok || a != 3 || b != 7 {
+		t.Errorf("unexpected single chat ids: %d %d %v", a, b, ok)
+	}
+	groupID, ok := GetGroupIDFromConversationID("group:42")
+	if !ok || groupID != "42" {
+		t.Errorf("unexpected group id: %s %v", groupID, ok)
+	}
+	if _, ok := GetGroupIDFromConversationID("single:1_2"); ok {
+		t.Errorf("single chat conversation should not parse as group")
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 单个事务内最多清理的消息数
+const retentionPurgeBatchSize = 500
+
+type SetConversationRetentionReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	RetentionDays  int    `json:"retention_days"` // 0 表示恢复使用全局策略
+}
+
+// SetConversationRetention 设置群聊历史消息保留天数，要求群主或管理员
+func (s *MessageService) SetConversationRetention(ctx context.Context, req SetConversationRetentionReq) error {
+	if req.RetentionDays < 0 {
+		return errs.ErrInvalidParam.WithDetail("invalid retention days")
+	}
+	groupID, ok := GetGroupIDFromConversationID(req.ConversationID)
+	if !ok {
+		return errs.ErrInvalidParam.WithDetail("只有群聊可以设置消息保留策略")
+	}
+	if err := s.checkGroupAdmin(ctx, groupID, req.UserID); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "conversation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"retention_days", "updated_by", "update_time"}),
+	}).Create(&model.ConversationSetting{
+		ConversationID: req.ConversationID,
+		RetentionDays:  req.RetentionDays,
+		UpdatedBy:      req.UserID,
+	}).Error
+}
+
+// retentionDays 按 群设置 > 会话类型 > 全局 的优先级返回保留天数，0 表示永久保留
+func retentionDays(conversationID string, groupDays int) int {
+	if groupDays > 0 {
+		return groupDays
+	}
+	switch GetConvTypeFromConversationID(conversationID) {
+	case constant.SingleChatType:
+		if conf.SingleChatRetentionDays > 0 {
+			return conf.SingleChatRetentionDays
+		}
+	case constant.GroupChatType:
+		if conf.GroupChatRetentionDays > 0 {
+			return conf.GroupChatRetentionDays
+		}
+	}
+	return conf.MsgRetentionDays
+}
+
+// ApplyMsgRetention 按保留策略截断一批会话的历史消息，返回下一批的游标，游标为空表示本轮已处理完
+func (s *MessageService) ApplyMsgRetention(ctx context.Context, cursor string, limit int) (string, error) {
+	var convIDs []string
+	if err := s.db.WithContext(ctx).Model(&model.SeqConversation{}).
+		Distinct("id").Where("id > ?", cursor).
+		Order("id").Limit(limit).
+		Pluck("id", &convIDs).Error; err != nil {
+		return "", err
+	}
+	if len(convIDs) == 0 {
+		return "", nil
+	}
+	var settings []model.ConversationSetting
+	if err := s.db.WithContext(ctx).Select("conversation_id", "retention_days").
+		Where("conversation_id IN ? AND retention_days > 0", convIDs).
+		Find(&settings).Error; err != nil {
+		return "", err
+	}
+	groupDays := make(map[string]int, len(settings))
+	for _, setting := range settings {
+		groupDays[setting.ConversationID] = setting.RetentionDays
+	}
+	for _, convID := range convIDs {
+		days := retentionDays(convID, groupDays[convID])
+		if days <= 0 {
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -days).UnixMilli()
+		minSeq, err := s.truncateConversation(ctx, convID, cutoff)
+		if err != nil {
+			return "", err
+		}
+		if minSeq > 0 {
+			if err := s.seqConvCache.DelMinSeqCache(ctx, convID); err != nil {
+				log.Printf("ApplyMsgRetention del min seq cache error: %v, conversationID: %v", err, convID)
+			}
+		}
+	}
+	if len(convIDs) < limit {
+		return "", nil
+	}
+	return convIDs[len(convIDs)-1], nil
+}
+
+// truncateConversation 清理会话中发送时间早于 cutoff 的消息。
+// 以最后一条过期消息的 seq 为界，之前的消息全部清理，MinSeq 与每批删除在同一事务中推进。
+// 保留期以天为单位，超过消息缓存时间，不需要再清理 MSG_CACHE。
+// 返回推进后的 MinSeq，0 表示没有需要截断的消息。
+func (s *MessageService) truncateConversation(ctx context.Context, conversationID string, cutoff int64) (int64, error) {
+	var boundary []int64
+	if err := s.db.WithContext(ctx).Model(&model.Message{}).
+		Where("conversation_id = ? AND send_time < ?", conversationID, cutoff).
+		Order("seq DESC").Limit(1).
+		Pluck("seq", &boundary).Error; err != nil {
+		return 0, err
+	}
+	if len(boundary) == 0 {
+		return 0, nil
+	}
+	for {
+		var n int
+		if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var msgs []*model.Message
+			if err := tx.Where("conversation_id = ? AND seq <= ?", conversationID, boundary[0]).
+				Order("seq").Limit(retentionPurgeBatchSize).
+				Find(&msgs).Error; err != nil {
+				return err
+			}
+			n = len(msgs)
+			// 最后一批为空时也要推进 MinSeq，覆盖之前已被删除的消息
+			minSeq := boundary[0] + 1
+			if n == retentionPurgeBatchSize {
+				minSeq = msgs[n-1].Seq + 1
+			}
+			if err := tx.Model(&model.SeqConversation{}).
+				Where("id = ? AND min_seq < ?", conversationID, minSeq).
+				Update("min_seq", minSeq).Error; err != nil {
+				return err
+			}
+			if n == 0 {
+				return nil
+			}
+			if conf.MsgRetentionArchive {
+				if err := archiveMsgsTx(tx, msgs); err != nil {
+					return err
+				}
+			}
+			ids := make([]int64, 0, n)
+			for _, msg := range msgs {
+				ids = append(ids, msg.ID)
+			}
+			return deleteMsgsTx(tx, ids)
+		}); err != nil {
+			return 0, err
+		}
+		if n < retentionPurgeBatchSize {
+			return boundary[0] + 1, nil
+		}
+	}
+}
+
+func archiveMsgsTx(tx *gorm.DB, msgs []*model.Message) error {
+	archives := make([]*model.MessageArchive, 0, len(msgs))
+	for _, msg := range msgs {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		archives = append(archives, &model.MessageArchive{
+			ID:             msg.ID,
+			ConversationID: msg.ConversationID,
+			Seq:            msg.Seq,
+			SendTime:       msg.SendTime,
+			Data:           string(data),
+		})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(archives).Error
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"backend/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRetentionDays(t *testing.T) {
+	old := conf
+	defer func() { conf = old }()
+	conf.MsgRetentionDays = 30
+	conf.GroupChatRetentionDays = 7
+
+	if d := retentionDays("single:1_2", 0); d != 30 {
+		t.Fatalf("single = %d, want 30", d)
+	}
+	if d := retentionDays("group:1", 0); d != 7 {
+		t.Fatalf("group = %d, want 7", d)
+	}
+	if d := retentionDays("group:1", 3); d != 3 {
+		t.Fatalf("group setting = %d, want 3", d)
+	}
+}
+
+func TestTruncateConversation(t *testing.T) {
+	old := conf
+	defer func() { conf = old }()
+	conf.MsgRetentionArchive = true
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Message{}, &model.SeqConversation{}, &model.MessageArchive{},
+		&model.MsgAt{}, &model.MsgSearchIndex{}, &model.MsgReaction{}, &model.MsgReactionCount{}, &model.MsgPin{}, &model.UserTimeline{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	convID := "group:1"
+	now := time.Now().UnixMilli()
+	day := int64(24 * time.Hour / time.Millisecond)
+
+	db.Create(&model.SeqConversation{ID: convID, SeqType: 2, MaxSeq: 4})
+	db.Create([]*model.Message{
+		{ID: 1, ConversationID: convID, Seq: 1, SenderID: 1, SendTime: now - 10*day},
+		{ID: 2, ConversationID: convID, Seq: 2, SenderID: 1, SendTime: now - 9*day},
+		{ID: 3, ConversationID: convID, Seq: 3, SenderID: 1, SendTime: now - day},
+		{ID: 4, ConversationID: convID, Seq: 4, SenderID: 1, SendTime: now},
+	})
+
+	minSeq, err := s.truncateConversation(ctx, convID, now-7*day)
+	if err != nil || minSeq != 3 {
+		t.Fatalf("truncateConversation = %d, %v, want 3", minSeq, err)
+	}
+	var seqConv model.SeqConversation
+	db.First(&seqConv, "id = ?", convID)
+	if seqConv.MinSeq != 3 {
+		t.Fatalf("min_seq = %d, want 3", seqConv.MinSeq)
+	}
+	var remain, archived int64
+	db.Model(&model.Message{}).Count(&remain)
+	db.Model(&model.MessageArchive{}).Count(&archived)
+	if remain != 2 || archived != 2 {
+		t.Fatalf("remain = %d, archived = %d, want 2, 2", remain, archived)
+	}
+
+	// 没有需要截断的消息
+	if minSeq, err := s.truncateConversation(ctx, convID, now-7*day); err != nil || minSeq != 0 {
+		t.Fatalf("truncateConversation = %d, %v, want 0", minSeq, err)
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/msgcontent"
+	"backend/internal/pkg/search"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 搜索结果摘要长度(字符)
+	searchSnippetLen  = 60
+	maxSearchPageSize = 100
+)
+
+type SearchMsgReq struct {
+	PagedParams
+	UserID         int64   `json:"user_id,string"`
+	ConversationID string  `json:"conversation_id"` // 为空时搜索用户的全部会话
+	Keyword        string  `json:"keyword"`
+	SenderID       int64   `json:"sender_id,string"`
+	MsgTypes       []int32 `json:"msg_types"`
+	StartTime      int64   `json:"start_time"` // 毫秒，包含
+	EndTime        int64   `json:"end_time"`   // 毫秒，不包含
+}
+
+type SearchMsgResult struct {
+	Message *model.Message `json:"message"`
+	Snippet string         `json:"snippet"` // 命中词以 <em></em> 标记
+}
+
+// BuildSearchIndex 生成消息的倒排索引行，不可检索的消息返回 nil
+func BuildSearchIndex(msg *model.Message) []*model.MsgSearchIndex {
+	text := msgcontent.SearchText(msg.MsgType, msg.Content)
+	if text == "" {
+		return nil
+	}
+	tokens := search.IndexTokens(text)
+	indices := make([]*model.MsgSearchIndex, 0, len(tokens))
+	for _, token := range tokens {
+		indices = append(indices, &model.MsgSearchIndex{
+			Token:          token,
+			ConversationID: msg.ConversationID,
+			MsgID:          msg.ID,
+			Seq:            msg.Seq,
+			SenderID:       msg.SenderID,
+			MsgType:        msg.MsgType,
+			SendTime:       msg.SendTime,
+		})
+	}
+	return indices
+}
+
+// SearchMsg 在单个会话或用户的全部会话中检索消息，按发送时间倒序。
+// 已撤回的消息和用户清空位点(SeqUser.MinSeq)之前的消息不会返回。
+func (s *MessageService) SearchMsg(ctx context.Context, req SearchMsgReq) (PagedResp[SearchMsgResult], error) {
+	tokens := search.QueryTokens(req.Keyword)
+	if len(tokens) == 0 && req.SenderID == 0 && len(req.MsgTypes) == 0 && req.StartTime == 0 && req.EndTime == 0 {
+		return PagedResp[SearchMsgResult]{}, errs.ErrInvalidParam.WithDetail("keyword or filter required")
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+	resp := PagedResp[SearchMsgResult]{Page: page, PageSize: pageSize, Data: []SearchMsgResult{}}
+
+	var convIDs []string
+	if req.ConversationID != "" {
+		if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+			return PagedResp[SearchMsgResult]{}, err
+		}
+		convIDs = []string{req.ConversationID}
+	} else {
+		ids, err := s.getSearchableConversationIDs(ctx, req.UserID)
+		if err != nil {
+			return PagedResp[SearchMsgResult]{}, err
+		}
+		convIDs = ids
+	}
+	if len(convIDs) == 0 {
+		return resp, nil
+	}
+	visible, err := s.visibleScope(ctx, req.UserID, convIDs)
+	if err != nil {
+		return PagedResp[SearchMsgResult]{}, err
+	}
+
+	var base *gorm.DB
+	if len(tokens) > 0 {
+		base = s.db.WithContext(ctx).Table("msg_search_indices AS i").
+			Select("i.msg_id AS id, MAX(i.send_time) AS send_time").
+			Joins("JOIN messages AS m ON m.id = i.msg_id AND m.status = 0").
+			Where("i.token IN ?", tokens).
+			Scopes(visible("i"), searchFilters("i", req)).
+			Group("i.msg_id").
+			Having("COUNT(DISTINCT i.token) = ?", len(tokens))
+	} else {
+		base = s.db.WithContext(ctx).Table("messages AS m").
+			Select("m.id AS id, m.send_time AS send_time").
+			Where("m.status = 0").
+			Scopes(visible("m"), searchFilters("m", req))
+	}
+	var total int64
+	if err := s.db.WithContext(ctx).Table("(?) AS hits", base.Session(&gorm.Session{})).Count(&total).Error; err != nil {
+		return PagedResp[SearchMsgResult]{}, err
+	}
+	resp.Total = int(total)
+	var hits []struct {
+		ID       int64
+		SendTime int64
+	}
+	if err := base.Session(&gorm.Session{}).
+		Order("send_time DESC").Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&hits).Error; err != nil {
+		return PagedResp[SearchMsgResult]{}, err
+	}
+	if len(hits) == 0 {
+		return resp, nil
+	}
+	ids := make([]int64, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	var msgs []*model.Message
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&msgs).Error; err != nil {
+		return PagedResp[SearchMsgResult]{}, err
+	}
+	msgMap := make(map[int64]*model.Message, len(msgs))
+	for _, msg := range msgs {
+		msgMap[msg.ID] = msg
+	}
+	for _, id := range ids {
+		msg, ok := msgMap[id]
+		if !ok {
+			continue
+		}
+		text := msgcontent.SearchText(msg.MsgType, msg.Content)
+		if text == "" {
+			text = msgcontent.Snapshot(msg.MsgType, msg.Content)
+		}
+		resp.Data = append(resp.Data, SearchMsgResult{
+			Message: msg,
+			Snippet: search.Highlight(text, req.Keyword, searchSnippetLen),
+		})
+	}
+	return resp, nil
+}
+
+// getSearchableConversationIDs 用户未隐藏的会话以及当前所在的群，退出的群不再可搜
+func (s *MessageService) getSearchableConversationIDs(ctx context.Context, userID int64) ([]string, error) {
+	var convIDs []string
+	if err := s.db.WithContext(ctx).Model(&model.Conversation{}).
+		Where("owner_id = ? AND status = ? AND conversation_id NOT LIKE ?", userID, conversationStatusNormal, "group:%").
+		Pluck("conversation_id", &convIDs).Error; err != nil {
+		return nil, err
+	}
+	var groupIDs []string
+	if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).
+		Where("user_id = ?", userID).
+		Pluck("group_id", &groupIDs).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(convIDs)+len(groupIDs))
+	result := make([]string, 0, len(convIDs)+len(groupIDs))
+	for _, id := range convIDs {
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	for _, gid := range groupIDs {
+		id := "group:" + gid
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// visibleScope 生成按会话可见位点过滤的条件：seq 不小于会话和用户两者中较大的 MinSeq
+func (s *MessageService) visibleScope(ctx context.Context, userID int64, convIDs []string) (func(table string) func(*gorm.DB) *gorm.DB, error) {
+	minSeqs := make(map[string]int64)
+	var convSeqs []model.SeqConversation
+	if err := s.db.WithContext(ctx).Select("id", "min_seq").
+		Where("id IN ? AND min_seq > 0", convIDs).
+		Find(&convSeqs).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range convSeqs {
+		minSeqs[c.ID] = c.MinSeq
+	}
+	var userSeqs []model.SeqUser
+	if err := s.db.WithContext(ctx).Select("conversation_id", "min_seq").
+		Where("user_id = ? AND conversation_id IN ? AND min_seq > 0", userID, convIDs).
+		Find(&userSeqs).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range userSeqs {
+		if u.MinSeq > minSeqs[u.ConversationID] {
+			minSeqs[u.ConversationID] = u.MinSeq
+		}
+	}
+	var plain []string
+	for _, id := range convIDs {
+		if _, ok := minSeqs[id]; !ok {
+			plain = append(plain, id)
+		}
+	}
+	return func(table string) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			cond := s.db.Where("1 = 0")
+			if len(plain) > 0 {
+				cond = cond.Or(table+".conversation_id IN ?", plain)
+			}
+			for id, minSeq := range minSeqs {
+				cond = cond.Or(table+".conversation_id = ? AND "+table+".seq >= ?", id, minSeq)
+			}
+			return db.Where(cond)
+		}
+	}, nil
+}
+
+func searchFilters(table string, req SearchMsgReq) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if req.SenderID != 0 {
+			db = db.Where(table+".sender_id = ?", req.SenderID)
+		}
+		if len(req.MsgTypes) > 0 {
+			db = db.Where(table+".msg_type IN ?", req.MsgTypes)
+		}
+		if req.StartTime > 0 {
+			db = db.Where(table+".send_time >= ?", req.StartTime)
+		}
+		if req.EndTime > 0 {
+			db = db.Where(table+".send_time < ?", req.EndTime)
+		}
+		return db
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSearchMsg(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Message{}, &model.MsgSearchIndex{}, &model.Conversation{},
+		&model.GroupMember{}, &model.SeqConversation{}, &model.SeqUser{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	conv := "single:1_2"
+	db.Create(&model.Conversation{OwnerID: 1, ConversationID: conv})
+	msgs := []*model.Message{
+		{ID: 1, ConversationID: conv, Seq: 1, SenderID: 1, MsgType: constant.MsgTypeText, Content: `{"content":"明天开会"}`, SendTime: 100},
+		{ID: 2, ConversationID: conv, Seq: 2, SenderID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"开会地点 Room 302"}`, SendTime: 200},
+		{ID: 3, ConversationID: conv, Seq: 3, SenderID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"开会取消"}`, SendTime: 300, Status: 1},
+		{ID: 4, ConversationID: conv, Seq: 4, SenderID: 2, MsgType: constant.MsgTypeImage, Content: `{"url":"http://x/1.png"}`, SendTime: 400},
+	}
+	db.Create(msgs)
+	for _, msg := range msgs {
+		if indices := BuildSearchIndex(msg); len(indices) > 0 {
+			db.Create(indices)
+		}
+	}
+
+	// 全部会话检索，已撤回的消息不返回，按时间倒序
+	resp, err := s.SearchMsg(ctx, SearchMsgReq{UserID: 1, Keyword: "开会"})
+	if err != nil {
+		t.Fatalf("SearchMsg failed: %v", err)
+	}
+	if resp.Total != 2 || len(resp.Data) != 2 || resp.Data[0].Message.ID != 2 || resp.Data[1].Message.ID != 1 {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+	if resp.Data[1].Snippet != "明天<em>开会</em>" {
+		t.Fatalf("unexpected snippet: %q", resp.Data[1].Snippet)
+	}
+
+	// 多个词需要同时命中
+	resp, err = s.SearchMsg(ctx, SearchMsgReq{UserID: 1, ConversationID: conv, Keyword: "开会 room"})
+	if err != nil || resp.Total != 1 || resp.Data[0].Message.ID != 2 {
+		t.Fatalf("unexpected result: %+v, err: %v", resp, err)
+	}
+
+	// 用户清空位点之前的消息不可见，MinSeq 本身可见
+	db.Create(&model.SeqUser{UserID: 1, ConversationID: conv, MinSeq: 2})
+	resp, err = s.SearchMsg(ctx, SearchMsgReq{UserID: 1, Keyword: "开会"})
+	if err != nil || resp.Total != 1 || resp.Data[0].Message.ID != 2 {
+		t.Fatalf("unexpected result: %+v, err: %v", resp, err)
+	}
+
+	// 已退出的群会话被隐藏，不再参与全部会话检索
+	group := "group:9"
+	db.Create(&model.Conversation{OwnerID: 1, ConversationID: group, Status: conversationStatusHidden})
+	groupMsg := &model.Message{ID: 5, ConversationID: group, Seq: 1, SenderID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"群里开会"}`, SendTime: 500}
+	db.Create(groupMsg)
+	db.Create(BuildSearchIndex(groupMsg))
+	resp, err = s.SearchMsg(ctx, SearchMsgReq{UserID: 1, Keyword: "开会"})
+	if err != nil || resp.Total != 1 || resp.Data[0].Message.ID != 2 {
+		t.Fatalf("unexpected result: %+v, err: %v", resp, err)
+	}
+
+	// 只按条件过滤
+	resp, err = s.SearchMsg(ctx, SearchMsgReq{UserID: 1, SenderID: 2, MsgTypes: []int32{constant.MsgTypeImage}})
+	if err != nil || resp.Total != 1 || resp.Data[0].Message.ID != 4 {
+		t.Fatalf("unexpected result: %+v, err: %v", resp, err)
+	}
+
+	// 非会话成员不能检索
+	if _, err := s.SearchMsg(ctx, SearchMsgReq{UserID: 3, ConversationID: conv, Keyword: "开会"}); err == nil {
+		t.Fatal("expected error for non-member")
+	}
+}
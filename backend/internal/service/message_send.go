@@ -0,0 +1,231 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/cache/redis"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
+	"backend/internal/pkg/notify"
+	"backend/internal/pkg/snowflake"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// 单条消息最多@的人数
+const maxAtUserNum = 100
+
+// ValidateSendMessage 网关投递 Kafka 之前对发送请求做校验，必要时会修正请求内容（如过滤无效的@对象）
+func (s *MessageService) ValidateSendMessage(ctx context.Context, req *SendMessageReq) error {
+	if req.SenderID == 0 || req.TargetID == 0 || req.Content == "" {
+		return errs.ErrInvalidParam
+	}
+	if GetConversationID(req.ConvType, req.SenderID, req.TargetID) == "" {
+		return errs.ErrInvalidParam.WithDetail("invalid conv type")
+	}
+	if !msgTypeAllowed(req) {
+		return errs.ErrInvalidParam.WithDetail("msg type not allowed")
+	}
+	if !req.serverSend {
+		req.RecvIDs = nil
+	}
+	// 合并转发的内容由服务端从源消息生成，客户端不能直接发送
+	if req.MsgType == constant.MsgTypeMerge && !req.forwardSend {
+		return errs.ErrInvalidParam.WithDetail("merge msg must be sent by forwarding")
+	}
+	if err := upgradeLegacyText(req); err != nil {
+		return err
+	}
+	if err := msgcontent.Validate(req.MsgType, req.Content); err != nil {
+		return errs.ErrMsgContentInvalid.WithDetail(err.Error())
+	}
+	if err := s.checkMentions(ctx, req); err != nil {
+		return err
+	}
+	if err := s.fillRefMsg(ctx, req); err != nil {
+		return err
+	}
+	if err := s.fillMsgTTL(ctx, req); err != nil {
+		return err
+	}
+	return nil
+}
+
+// msgTypeAllowed 信令、群事件只能由服务端产生，管理后台只能发送内容消息和业务通知
+func msgTypeAllowed(req *SendMessageReq) bool {
+	if req.MsgType < constant.MsgTypeRevoke || req.serverSend {
+		return true
+	}
+	if req.adminSend {
+		return req.MsgType >= constant.MsgTypeFriendApplyNotification && req.MsgType <= constant.MsgTypeAccountNotification
+	}
+	return false
+}
+
+// upgradeLegacyText 兼容旧版客户端：msg_type 为 1 的纯文本包装为 TextElem
+func upgradeLegacyText(req *SendMessageReq) error {
+	if req.MsgType != constant.MsgTypeLegacyText {
+		return nil
+	}
+	content, err := json.Marshal(msgcontent.TextElem{Content: req.Content})
+	if err != nil {
+		return errs.ErrMsgContentInvalid.WithDetail(err.Error())
+	}
+	req.MsgType, req.Content = constant.MsgTypeText, string(content)
+	return nil
+}
+
+// checkMentions 校验@信息：仅群聊可用，@所有人要求群主或管理员，@对象必须是群成员
+func (s *MessageService) checkMentions(ctx context.Context, req *SendMessageReq) error {
+	if len(req.AtUserIDs) == 0 && !req.IsAtAll {
+		return nil
+	}
+	if req.ConvType != constant.GroupChatType {
+		return errs.ErrInvalidParam.WithDetail("只有群聊消息可以@成员")
+	}
+	groupID := strconv.FormatInt(req.TargetID, 10)
+	if req.IsAtAll {
+		var sender model.GroupMember
+		if err := s.db.WithContext(ctx).Select("role_level").
+			Where("group_id = ? AND user_id = ?", groupID, req.SenderID).
+			First(&sender).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.ErrNotConversationMember
+			}
+			return err
+		}
+		if sender.RoleLevel < roleAdmin {
+			return errs.ErrAtAllPermissionDenied
+		}
+	}
+	atUserIDs := make([]int64, 0, len(req.AtUserIDs))
+	seen := make(map[int64]struct{}, len(req.AtUserIDs))
+	for _, id := range req.AtUserIDs {
+		if id == 0 || id == req.SenderID {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		atUserIDs = append(atUserIDs, id)
+	}
+	if len(atUserIDs) > maxAtUserNum {
+		return errs.ErrInvalidParam.WithDetail("@人数超过上限")
+	}
+	if len(atUserIDs) > 0 {
+		var memberIDs []int64
+		if err := s.db.WithContext(ctx).Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id IN ?", groupID, atUserIDs).
+			Pluck("user_id", &memberIDs).Error; err != nil {
+			return err
+		}
+		atUserIDs = memberIDs
+	}
+	req.AtUserIDs = atUserIDs
+	return nil
+}
+
+type SendMessageResp struct {
+	ServerMsgID int64  `json:"server_msg_id,string"`
+	ClientMsgID string `json:"client_msg_id"`
+	Seq         int64  `json:"seq"`        // 重复发送且原消息已分配 seq 时返回
+	Duplicated  bool   `json:"duplicated"` // true 表示本次是重试，未产生新消息
+}
+
+// PrepareSendMessage 为消息分配服务端ID，并按发送者 + ClientMsgID 登记去重。
+// 窗口期内的重试返回原消息的ID和seq，调用方不应再投递。
+func (s *MessageService) PrepareSendMessage(ctx context.Context, req *SendMessageReq) (*SendMessageResp, error) {
+	req.ServerMsgID = snowflake.GenID()
+	resp := &SendMessageResp{ServerMsgID: req.ServerMsgID, ClientMsgID: req.ClientMsgID}
+	if req.ClientMsgID == "" {
+		return resp, nil
+	}
+	rec, reserved, err := s.dedupCache.Reserve(ctx, req.SenderID, req.ClientMsgID, redis.MsgDedupRecord{MsgID: req.ServerMsgID})
+	if err != nil {
+		// 去重失败不阻塞发送，分发端还会再检查一次
+		log.Printf("PrepareSendMessage reserve client msg id error: %v, senderID: %v", err, req.SenderID)
+		return resp, nil
+	}
+	if !reserved {
+		resp.ServerMsgID = rec.MsgID
+		resp.Seq = rec.Seq
+		resp.Duplicated = true
+	}
+	return resp, nil
+}
+
+// ReleaseSendMessage 消息投递失败时撤销去重登记，客户端可以用同一 ClientMsgID 重试
+func (s *MessageService) ReleaseSendMessage(ctx context.Context, req *SendMessageReq) {
+	if req.ClientMsgID == "" {
+		return
+	}
+	if err := s.dedupCache.Release(ctx, req.SenderID, req.ClientMsgID); err != nil {
+		log.Printf("ReleaseSendMessage error: %v, senderID: %v", err, req.SenderID)
+	}
+}
+
+// ClaimSendMessage 分发端在分配 seq 前调用，返回 false 表示该消息已处理过（客户端重试或 Kafka 重复投递）
+func (s *MessageService) ClaimSendMessage(ctx context.Context, req *SendMessageReq) bool {
+	if req.ServerMsgID == 0 {
+		req.ServerMsgID = snowflake.GenID()
+	}
+	if req.ClientMsgID == "" {
+		return true
+	}
+	rec, reserved, err := s.dedupCache.Reserve(ctx, req.SenderID, req.ClientMsgID, redis.MsgDedupRecord{MsgID: req.ServerMsgID})
+	if err != nil {
+		log.Printf("ClaimSendMessage reserve client msg id error: %v, senderID: %v", err, req.SenderID)
+		return true
+	}
+	// 网关已登记且尚未分配 seq 的是本条消息自己
+	return reserved || (rec.MsgID == req.ServerMsgID && rec.Seq == 0)
+}
+
+// CompleteSendMessage 消息分配 seq 后回填去重记录
+func (s *MessageService) CompleteSendMessage(ctx context.Context, msgs []*model.Message) {
+	if err := s.dedupCache.Complete(ctx, msgs); err != nil {
+		log.Printf("CompleteSendMessage error: %v", err)
+	}
+}
+
+// SendMessageResult 消息分发完成后回推给发送连接的结果，ErrCode 非 0 表示失败
+type SendMessageResult struct {
+	MsgIncr        string `json:"msg_incr"`
+	ClientMsgID    string `json:"client_msg_id"`
+	ServerMsgID    int64  `json:"server_msg_id,string"`
+	ConversationID string `json:"conversation_id"`
+	Seq            int64  `json:"seq"`
+	SendTime       int64  `json:"send_time"`
+	ErrCode        int    `json:"err_code"`
+	ErrMsg         string `json:"err_msg,omitempty"`
+}
+
+// PushSendResult 把发送结果推给发起请求的连接，HTTP 等没有连接的请求直接忽略
+func PushSendResult(ctx context.Context, req *SendMessageReq, msg *model.Message, sendErr error) {
+	if req.SenderConnID == "" {
+		return
+	}
+	result := SendMessageResult{
+		MsgIncr:     req.MsgIncr,
+		ClientMsgID: req.ClientMsgID,
+		ServerMsgID: req.ServerMsgID,
+	}
+	if msg != nil {
+		result.ConversationID = msg.ConversationID
+		result.Seq = msg.Seq
+		result.SendTime = msg.SendTime
+	}
+	if sendErr != nil {
+		codeErr := toCodeError(sendErr)
+		result.ErrCode, result.ErrMsg = codeErr.Code, codeErr.Msg
+	}
+	if err := notify.PushToConn(ctx, constant.MsgTypeSendResult, req.SenderID, req.SenderConnID, result.ConversationID, result); err != nil {
+		log.Printf("PushSendResult error: %v, senderID: %v", err, req.SenderID)
+	}
+}
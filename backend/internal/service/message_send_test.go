@@ -0,0 +1,95 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestValidateSendMessageLegacyText(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.ConversationSetting{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	// 旧版客户端的纯文本消息
+	req := &SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: 1, Content: "hello"}
+	if err := s.ValidateSendMessage(ctx, req); err != nil {
+		t.Fatalf("ValidateSendMessage: %v", err)
+	}
+	if req.MsgType != constant.MsgTypeText || req.Content != `{"content":"hello"}` {
+		t.Fatalf("legacy text not upgraded: %d %s", req.MsgType, req.Content)
+	}
+
+	req = &SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: 1, Content: "  "}
+	if err := s.ValidateSendMessage(ctx, req); err == nil {
+		t.Fatal("expected error for blank legacy text")
+	}
+}
+
+func TestValidateSendMessageServerMsgType(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.ConversationSetting{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	// 客户端不能伪造群事件和系统通知
+	forged := []*SendMessageReq{
+		{SenderID: 1, ConvType: constant.GroupChatType, TargetID: 9, MsgType: constant.MsgTypeGroupDismissed, Content: `{"group_id":"9","operator_user_id":"1"}`},
+		{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeAccountNotification, Content: `{"event":"login"}`},
+	}
+	for _, req := range forged {
+		if err := s.ValidateSendMessage(ctx, req); err == nil || toCodeError(err).Code != errs.ErrCodeInvalidParam {
+			t.Fatalf("msg type %d err = %v, want invalid param", req.MsgType, err)
+		}
+	}
+	req := &SendMessageReq{SenderID: 1, ConvType: constant.GroupChatType, TargetID: 9, MsgType: constant.MsgTypeGroupDismissed,
+		Content: `{"group_id":"9","operator_user_id":"1"}`, serverSend: true}
+	if err := s.ValidateSendMessage(ctx, req); err != nil {
+		t.Fatalf("server msg err = %v", err)
+	}
+	// 管理后台可以发业务通知，但不能伪造信令和群事件
+	req = &SendMessageReq{SenderID: 1, ConvType: constant.NotificationChatType, TargetID: 2, MsgType: constant.MsgTypeAccountNotification,
+		Content: `{"event":"login"}`, adminSend: true}
+	if err := s.ValidateSendMessage(ctx, req); err != nil {
+		t.Fatalf("admin notification err = %v", err)
+	}
+	req = &SendMessageReq{SenderID: 1, ConvType: constant.GroupChatType, TargetID: 9, MsgType: constant.MsgTypeGroupDismissed,
+		Content: `{"group_id":"9","operator_user_id":"1"}`, adminSend: true}
+	if err := s.ValidateSendMessage(ctx, req); err == nil || toCodeError(err).Code != errs.ErrCodeInvalidParam {
+		t.Fatalf("admin group event err = %v, want invalid param", err)
+	}
+}
+
+func TestValidateSendMessageMerge(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.ConversationSetting{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	content := `{"title":"聊天记录","messages":[{"sender_id":"3","msg_type":101,"content":"{\"content\":\"fake\"}","send_time":1}]}`
+	req := &SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeMerge, Content: content}
+	if err := s.ValidateSendMessage(ctx, req); err == nil || toCodeError(err).Code != errs.ErrCodeInvalidParam {
+		t.Fatalf("client merge err = %v, want invalid param", err)
+	}
+}
@@ -251,7 +251,7 @@ func TestGetMaxSeq(t *testing.T) {
 
 	// 3. Execute
 
-	resp, err := svc.GetMaxSeq(ctx, userID)
+	resp, err := svc.GetMaxSeq(ctx, userID, "")
 
 	// 4. Assert
 	if err != nil {
@@ -271,7 +271,7 @@ func TestGetMaxSeq(t *testing.T) {
 	}
 
 	// Test Cache Hit (Optional: Run again and check logs or coverage, but hard to assert without mocking)
-	resp2, err := svc.GetMaxSeq(ctx, userID)
+	resp2, err := svc.GetMaxSeq(ctx, userID, "")
 	if err != nil {
 		t.Fatalf("GetMaxSeq (2nd call) failed: %v", err)
 	}
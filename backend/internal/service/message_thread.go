@@ -0,0 +1,183 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/msgcontent"
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 引用快照中文本的最大长度(字符)
+	maxQuoteSnapshotLen = 100
+	// 话题回复分页默认/最大条数
+	defaultThreadPageSize = 20
+	maxThreadPageSize     = 100
+	// 一次最多查询多少条消息的回复数
+	maxReplyCountMsgs = 100
+)
+
+// fillRefMsg 校验被回复的消息并生成引用快照。
+// 回复的是话题中的某条回复时，RootMsgID 沿用原话题的根消息，话题只有一层。
+func (s *MessageService) fillRefMsg(ctx context.Context, req *SendMessageReq) error {
+	req.RootMsgID = 0
+	req.RefMsg = nil
+	if req.RefMsgID == 0 {
+		return nil
+	}
+	// 消息是异步落库的，刚发出的消息可能暂时查不到
+	var ref model.Message
+	if err := s.db.WithContext(ctx).Where("id = ?", req.RefMsgID).First(&ref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.ErrMsgNotFound
+		}
+		return err
+	}
+	if ref.ConversationID != GetConversationID(req.ConvType, req.SenderID, req.TargetID) {
+		return errs.ErrInvalidParam.WithDetail("只能回复同一会话中的消息")
+	}
+	req.RootMsgID = ref.ID
+	if ref.RootMsgID != 0 {
+		req.RootMsgID = ref.RootMsgID
+	}
+	req.RefMsg = newQuoteMsg(&ref)
+	return nil
+}
+
+func newQuoteMsg(msg *model.Message) *model.QuoteMsg {
+	quote := &model.QuoteMsg{
+		MsgID:    msg.ID,
+		Seq:      msg.Seq,
+		SenderID: msg.SenderID,
+		MsgType:  msg.MsgType,
+		SendTime: msg.SendTime,
+	}
+	if msg.Status == 1 {
+		quote.Revoked = true
+		return quote
+	}
+	quote.Snapshot = msgSnapshot(msg.MsgType, msg.Content)
+	return quote
+}
+
+// msgSnapshot 生成消息摘要，过长时截断
+func msgSnapshot(msgType int32, content string) string {
+	snapshot := msgcontent.Snapshot(msgType, content)
+	if utf8.RuneCountInString(snapshot) <= maxQuoteSnapshotLen {
+		return snapshot
+	}
+	return string([]rune(snapshot)[:maxQuoteSnapshotLen]) + "..."
+}
+
+type GetMsgThreadReq struct {
+	UserID         int64  `json:"user_id,string"`
+	ConversationID string `json:"conversation_id" binding:"required"`
+	RootMsgID      int64  `json:"root_msg_id,string" binding:"required"`
+	StartSeq       int64  `json:"start_seq"` // 返回 seq 大于该值的回复，首页传 0
+	Count          int    `json:"count"`
+}
+
+type GetMsgThreadResp struct {
+	Root       *model.Message   `json:"root"`
+	Replies    []*model.Message `json:"replies"`
+	ReplyCount int64            `json:"reply_count"`
+	IsEnd      bool             `json:"is_end"`
+	EndSeq     int64            `json:"end_seq"` // 下一页的 start_seq
+}
+
+// GetMsgThread 查询话题根消息及其回复，按 seq 升序分页
+func (s *MessageService) GetMsgThread(ctx context.Context, req GetMsgThreadReq) (GetMsgThreadResp, error) {
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return GetMsgThreadResp{}, err
+	}
+	if req.Count <= 0 {
+		req.Count = defaultThreadPageSize
+	}
+	if req.Count > maxThreadPageSize {
+		req.Count = maxThreadPageSize
+	}
+	var root model.Message
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND conversation_id = ?", req.RootMsgID, req.ConversationID).
+		First(&root).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return GetMsgThreadResp{}, errs.ErrMsgNotFound
+		}
+		return GetMsgThreadResp{}, err
+	}
+	var replies []*model.Message
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND root_msg_id = ? AND seq > ?", req.ConversationID, req.RootMsgID, req.StartSeq).
+		Order("seq ASC").
+		Limit(req.Count + 1).
+		Find(&replies).Error; err != nil {
+		return GetMsgThreadResp{}, err
+	}
+	counts, err := s.countReplies(ctx, req.ConversationID, []int64{req.RootMsgID})
+	if err != nil {
+		return GetMsgThreadResp{}, err
+	}
+	resp := GetMsgThreadResp{
+		Root:       &root,
+		ReplyCount: counts[req.RootMsgID],
+		IsEnd:      len(replies) <= req.Count,
+		EndSeq:     req.StartSeq,
+	}
+	if !resp.IsEnd {
+		replies = replies[:req.Count]
+	}
+	if len(replies) > 0 {
+		resp.EndSeq = replies[len(replies)-1].Seq
+	}
+	resp.Replies = replies
+	return resp, nil
+}
+
+type GetMsgReplyCountsReq struct {
+	UserID         int64   `json:"user_id,string"`
+	ConversationID string  `json:"conversation_id" binding:"required"`
+	MsgIDs         []int64 `json:"msg_ids" binding:"required"`
+}
+
+type GetMsgReplyCountsResp struct {
+	// 根消息ID -> 回复数，没有回复的消息不返回
+	Counts map[int64]int64 `json:"counts"`
+}
+
+// GetMsgReplyCounts 批量查询话题根消息的回复数
+func (s *MessageService) GetMsgReplyCounts(ctx context.Context, req GetMsgReplyCountsReq) (GetMsgReplyCountsResp, error) {
+	if len(req.MsgIDs) > maxReplyCountMsgs {
+		return GetMsgReplyCountsResp{}, errs.ErrInvalidParam.WithDetail("查询的消息数超过上限")
+	}
+	if err := s.checkConversationMember(ctx, req.UserID, req.ConversationID); err != nil {
+		return GetMsgReplyCountsResp{}, err
+	}
+	counts, err := s.countReplies(ctx, req.ConversationID, req.MsgIDs)
+	if err != nil {
+		return GetMsgReplyCountsResp{}, err
+	}
+	return GetMsgReplyCountsResp{Counts: counts}, nil
+}
+
+func (s *MessageService) countReplies(ctx context.Context, conversationID string, rootMsgIDs []int64) (map[int64]int64, error) {
+	var rows []struct {
+		RootMsgID int64
+		Count     int64
+	}
+	if err := s.db.WithContext(ctx).Model(&model.Message{}).
+		Select("root_msg_id, COUNT(*) AS count").
+		Where("conversation_id = ? AND root_msg_id IN ?", conversationID, rootMsgIDs).
+		Group("root_msg_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(rows))
+	for _, r := range rows {
+		counts[r.RootMsgID] = r.Count
+	}
+	return counts, nil
+}
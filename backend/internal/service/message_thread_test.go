@@ -0,0 +1,35 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"strings"
+	"testing"
+)
+
+func TestMsgSnapshot(t *testing.T) {
+	if got := msgSnapshot(constant.MsgTypeText, `{"content":"你好"}`); got != "你好" {
+		t.Fatalf("short text snapshot = %q", got)
+	}
+	long := `{"content":"` + strings.Repeat("字", maxQuoteSnapshotLen+10) + `"}`
+	got := msgSnapshot(constant.MsgTypeText, long)
+	if want := strings.Repeat("字", maxQuoteSnapshotLen) + "..."; got != want {
+		t.Fatalf("long text snapshot = %q, want %q", got, want)
+	}
+	if got := msgSnapshot(constant.MsgTypeImage, `{"url":"http://x/1.png"}`); got != "[图片]" {
+		t.Fatalf("image snapshot = %q", got)
+	}
+}
+
+func TestNewQuoteMsg(t *testing.T) {
+	msg := &model.Message{ID: 1, Seq: 2, SenderID: 3, MsgType: constant.MsgTypeText, Content: `{"content":"hi"}`, SendTime: 4}
+	q := newQuoteMsg(msg)
+	if q.MsgID != 1 || q.Seq != 2 || q.SenderID != 3 || q.Snapshot != "hi" || q.Revoked {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	msg.Status = 1
+	q = newQuoteMsg(msg)
+	if !q.Revoked || q.Snapshot != "" {
+		t.Fatalf("revoked quote should hide content: %+v", q)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/kafka"
+	"backend/internal/pkg/prommetrics"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// MsgProducer 校验消息并投递到 coming_message_topic，网关和定时任务等服务端发送方共用
+type MsgProducer struct {
+	messageService *MessageService
+	producer       sarama.SyncProducer
+}
+
+func NewMsgProducer(messageService *MessageService, producer sarama.SyncProducer) *MsgProducer {
+	return &MsgProducer{
+		messageService: messageService,
+		producer:       producer,
+	}
+}
+
+// Send 校验、去重后投递消息。重复发送时返回原消息，不会再次投递。
+func (p *MsgProducer) Send(ctx context.Context, req *SendMessageReq) (*SendMessageResp, error) {
+	if p.producer == nil {
+		return nil, errs.ErrInternalServer.WithDetail("kafka producer unavailable")
+	}
+	if err := p.messageService.ValidateSendMessage(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := p.messageService.checkSingleChatRelation(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := p.messageService.checkGroupMute(ctx, req); err != nil {
+		return nil, err
+	}
+	// 先回调再审核，业务方改写后的内容同样要经过审核
+	if err := callbackBeforeSendMsg(ctx, req); err != nil {
+		return nil, err
+	}
+	modRecord, err := p.messageService.ModerateSendMessage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.messageService.PrepareSendMessage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Duplicated {
+		log.Printf("MsgProducer duplicated clientMsgID=%s serverMsgID=%d", req.ClientMsgID, resp.ServerMsgID)
+		return resp, nil
+	}
+	value, err := json.Marshal(req)
+	if err != nil {
+		p.messageService.ReleaseSendMessage(ctx, req)
+		return nil, err
+	}
+	partition, offset, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: kafka.ComingMessageTopic,
+		Value: sarama.ByteEncoder(value),
+	})
+	if err != nil {
+		prommetrics.MsgProcessFailedCounter.Inc()
+		log.Printf("FAILED to send kafka message: %v", err)
+		p.messageService.ReleaseSendMessage(ctx, req)
+		return nil, err
+	}
+	prommetrics.MsgProcessSuccessCounter.Inc()
+	callbackAfterSendMsg(req, resp.ServerMsgID)
+	if modRecord != nil {
+		modRecord.ServerMsgID = resp.ServerMsgID
+		go p.messageService.saveModerationRecord(context.Background(), modRecord)
+	}
+	log.Printf("message sent to partition=%d offset=%d", partition, offset)
+	return resp, nil
+}
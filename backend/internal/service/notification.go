@@ -0,0 +1,44 @@
+package service
+
+import (
+	"backend/internal/pkg/constant"
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// systemMsgProducer 投递系统通知和群事件等服务端产生的消息，未初始化时只记录日志
+var systemMsgProducer *MsgProducer
+
+// InitSystemMsgProducer 设置服务端消息使用的生产者，启动时调用一次
+func InitSystemMsgProducer(producer *MsgProducer) {
+	systemMsgProducer = producer
+}
+
+// sendNotification 向用户的通知会话投递一条系统通知，发送者为触发事件的用户。
+// 通知和普通消息一样分配 seq、落库，客户端用相同的拉取接口同步。
+// 投递失败不影响触发通知的业务，只记录日志。
+func sendNotification(ctx context.Context, senderID int64, recvIDs []int64, msgType int32, elem any) {
+	if systemMsgProducer == nil {
+		log.Printf("sendNotification: producer not initialized, msgType: %d", msgType)
+		return
+	}
+	content, err := json.Marshal(elem)
+	if err != nil {
+		log.Printf("sendNotification marshal error: %v, msgType: %d", err, msgType)
+		return
+	}
+	for _, recvID := range uniqueIDs(recvIDs) {
+		req := &SendMessageReq{
+			SenderID:   senderID,
+			ConvType:   constant.NotificationChatType,
+			TargetID:   recvID,
+			MsgType:    msgType,
+			Content:    string(content),
+			serverSend: true,
+		}
+		if _, err := systemMsgProducer.Send(ctx, req); err != nil {
+			log.Printf("sendNotification error: %v, msgType: %d, recvID: %d", err, msgType, recvID)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/offlinepush"
+	"context"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PushService 设备推送 token 管理以及离线推送的接收者过滤
+type PushService struct {
+	db *gorm.DB
+}
+
+func NewPushService(db *gorm.DB) *PushService {
+	return &PushService{db: db}
+}
+
+type RegisterDeviceTokenReq struct {
+	UserID   int64  `json:"user_id,string"`
+	DeviceID string `json:"device_id" binding:"required"`
+	Provider string `json:"provider" binding:"required"` // apns/fcm/厂商通道名
+	Token    string `json:"token" binding:"required"`
+}
+
+type UnregisterDeviceTokenReq struct {
+	UserID   int64  `json:"user_id,string"`
+	DeviceID string `json:"device_id" binding:"required"`
+}
+
+// RegisterDeviceToken 上报设备推送 token。同一 token 只归属最后上报的用户和设备，换账号登录后旧账号不会再收到推送
+func (s *PushService) RegisterDeviceToken(ctx context.Context, req RegisterDeviceTokenReq) error {
+	if len(req.DeviceID) > 64 || len(req.Provider) > 32 || len(req.Token) > 255 {
+		return errs.ErrInvalidParam.WithDetail("device_id, provider or token too long")
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("provider = ? AND token = ? AND NOT (user_id = ? AND device_id = ?)", req.Provider, req.Token, req.UserID, req.DeviceID).
+			Delete(&model.DeviceToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"provider", "token", "update_time"}),
+		}).Create(&model.DeviceToken{
+			UserID:   req.UserID,
+			DeviceID: req.DeviceID,
+			Provider: req.Provider,
+			Token:    req.Token,
+		}).Error
+	})
+}
+
+// UnregisterDeviceToken 退出登录或关闭推送时删除设备 token
+func (s *PushService) UnregisterDeviceToken(ctx context.Context, req UnregisterDeviceTokenReq) error {
+	return s.db.WithContext(ctx).Where("user_id = ? AND device_id = ?", req.UserID, req.DeviceID).Delete(&model.DeviceToken{}).Error
+}
+
+func (s *PushService) GetDeviceTokens(ctx context.Context, userID int64) ([]model.DeviceToken, error) {
+	var tokens []model.DeviceToken
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteDeviceTokens 删除推送通道返回的失效 token
+func (s *PushService) DeleteDeviceTokens(ctx context.Context, provider string, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Where("provider = ? AND token IN ?", provider, tokens).Delete(&model.DeviceToken{}).Error
+}
+
+// offlinePushable 只有聊天消息和系统通知需要离线推送，信令和群事件不推送
+func offlinePushable(msgType int32) bool {
+	return msgType < constant.MsgTypeRevoke || msgType >= constant.MsgTypeFriendApplyNotification
+}
+
+// FilterOfflinePushUsers 过滤掉开启了全局免打扰或会话免打扰的用户，被@时不受免打扰限制
+func (s *PushService) FilterOfflinePushUsers(ctx context.Context, msg *model.Message, userIDs []int64) ([]int64, error) {
+	if !offlinePushable(msg.MsgType) || len(userIDs) == 0 {
+		return nil, nil
+	}
+	mentioned := make(map[int64]bool, len(msg.AtUserIDs))
+	for _, id := range msg.AtUserIDs {
+		mentioned[id] = true
+	}
+	candidates := make([]int64, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !msg.IsAtAll && !mentioned[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	silent := make(map[int64]bool)
+	if len(candidates) > 0 {
+		var dndIDs []int64
+		if err := s.db.WithContext(ctx).Model(&model.User{}).
+			Where("user_id IN ? AND global_recv_msg_opt = ?", candidates, 1).
+			Pluck("user_id", &dndIDs).Error; err != nil {
+			return nil, err
+		}
+		var mutedIDs []int64
+		if err := s.db.WithContext(ctx).Model(&model.Conversation{}).
+			Where("owner_id IN ? AND conversation_id = ? AND is_muted = ?", candidates, msg.ConversationID, true).
+			Pluck("owner_id", &mutedIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range append(dndIDs, mutedIDs...) {
+			silent[id] = true
+		}
+	}
+	result := make([]int64, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !silent[id] {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// BuildOfflineNotification 生成推送标题和摘要：单聊显示发送者昵称，群聊显示群名和"昵称: 摘要"
+func (s *PushService) BuildOfflineNotification(ctx context.Context, msg *model.Message) (*offlinepush.Notification, error) {
+	body := msgSnapshot(msg.MsgType, msg.Content)
+	if msg.BurnAfterRead {
+		body = "[阅后即焚消息]"
+	}
+	n := &offlinepush.Notification{
+		Body:        body,
+		CollapseKey: msg.ConversationID,
+		Data: map[string]string{
+			"conversation_id": msg.ConversationID,
+			"msg_id":          strconv.FormatInt(msg.ID, 10),
+			"seq":             strconv.FormatInt(msg.Seq, 10),
+		},
+	}
+	if msg.ConvType == constant.NotificationChatType {
+		n.Title = "系统通知"
+		return n, nil
+	}
+	var sender model.User
+	if err := s.db.WithContext(ctx).Select("nickname", "username").Where("user_id = ?", msg.SenderID).Limit(1).Find(&sender).Error; err != nil {
+		return nil, err
+	}
+	senderName := sender.Nickname
+	if senderName == "" {
+		senderName = sender.Username
+	}
+	n.Title = senderName
+	if msg.ConvType == constant.GroupChatType {
+		var group model.Group
+		if err := s.db.WithContext(ctx).Select("group_name").Where("id = ?", msg.TargetID).Limit(1).Find(&group).Error; err != nil {
+			return nil, err
+		}
+		n.Title = group.GroupName
+		n.Body = senderName + ": " + body
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"context"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPushService(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.Group{}, &model.Conversation{}, &model.DeviceToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := NewPushService(db)
+	ctx := context.Background()
+
+	// 同一 token 换账号上报后只属于新账号
+	if err := s.RegisterDeviceToken(ctx, RegisterDeviceTokenReq{UserID: 1, DeviceID: "iphone", Provider: "apns", Token: "t1"}); err != nil {
+		t.Fatalf("RegisterDeviceToken: %v", err)
+	}
+	if err := s.RegisterDeviceToken(ctx, RegisterDeviceTokenReq{UserID: 2, DeviceID: "iphone", Provider: "apns", Token: "t1"}); err != nil {
+		t.Fatalf("RegisterDeviceToken: %v", err)
+	}
+	if err := s.RegisterDeviceToken(ctx, RegisterDeviceTokenReq{UserID: 2, DeviceID: "iphone", Provider: "apns", Token: "t2"}); err != nil {
+		t.Fatalf("RegisterDeviceToken update: %v", err)
+	}
+	if tokens, _ := s.GetDeviceTokens(ctx, 1); len(tokens) != 0 {
+		t.Fatalf("user 1 tokens = %+v", tokens)
+	}
+	if tokens, _ := s.GetDeviceTokens(ctx, 2); len(tokens) != 1 || tokens[0].Token != "t2" {
+		t.Fatalf("user 2 tokens = %+v", tokens)
+	}
+	if err := s.UnregisterDeviceToken(ctx, UnregisterDeviceTokenReq{UserID: 2, DeviceID: "iphone"}); err != nil {
+		t.Fatalf("UnregisterDeviceToken: %v", err)
+	}
+	if tokens, _ := s.GetDeviceTokens(ctx, 2); len(tokens) != 0 {
+		t.Fatalf("tokens after unregister = %+v", tokens)
+	}
+
+	// 3 全局免打扰，4 会话免打扰，5 会话免打扰但被@
+	db.Create(&model.User{UserID: 1, Username: "alice", Nickname: "Alice"})
+	db.Create(&model.User{UserID: 3, Username: "u3", GlobalRecvMsgOpt: 1})
+	db.Create(&model.Group{ID: 9, GroupName: "team"})
+	convID := GetConversationID(constant.GroupChatType, 1, 9)
+	db.Create(&model.Conversation{OwnerID: 4, ConversationID: convID, ConvType: constant.GroupChatType, IsMuted: true})
+	db.Create(&model.Conversation{OwnerID: 5, ConversationID: convID, ConvType: constant.GroupChatType, IsMuted: true})
+	msg := &model.Message{ID: 100, ConversationID: convID, Seq: 7, SenderID: 1, ConvType: constant.GroupChatType, TargetID: 9,
+		MsgType: constant.MsgTypeText, Content: `{"content":"hi"}`, AtUserIDs: []int64{5}}
+	users, err := s.FilterOfflinePushUsers(ctx, msg, []int64{2, 3, 4, 5})
+	if err != nil || !reflect.DeepEqual(users, []int64{2, 5}) {
+		t.Fatalf("FilterOfflinePushUsers = %v, %v", users, err)
+	}
+	msg.IsAtAll = true
+	if users, _ := s.FilterOfflinePushUsers(ctx, msg, []int64{2, 3, 4}); !reflect.DeepEqual(users, []int64{2, 3, 4}) {
+		t.Fatalf("at all users = %v", users)
+	}
+	if users, _ := s.FilterOfflinePushUsers(ctx, &model.Message{MsgType: constant.MsgTypeMemberJoin}, []int64{2}); len(users) != 0 {
+		t.Fatalf("group event should not be pushed: %v", users)
+	}
+
+	n, err := s.BuildOfflineNotification(ctx, msg)
+	if err != nil || n.Title != "team" || n.Body != "Alice: hi" || n.CollapseKey != convID || n.Data["seq"] != "7" {
+		t.Fatalf("BuildOfflineNotification = %+v, %v", n, err)
+	}
+}
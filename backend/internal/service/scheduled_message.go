@@ -0,0 +1,304 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/snowflake"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScheduledMsgPending  int32 = 0
+	ScheduledMsgSending  int32 = 1
+	ScheduledMsgSent     int32 = 2
+	ScheduledMsgCanceled int32 = 3
+	ScheduledMsgFailed   int32 = 4
+)
+
+const (
+	// 最多提前多久定时
+	maxScheduleAhead = 30 * 24 * time.Hour
+	// 每个用户最多的待发送定时消息数
+	maxPendingScheduledMsgs = 100
+	// 投递失败(非校验错误)的最大重试次数
+	maxScheduledMsgRetry = 5
+	// 发送中超过该时间视为实例崩溃，重新放回待发送
+	scheduledMsgClaimTimeout = 5 * time.Minute
+)
+
+type CreateScheduledMsgReq struct {
+	SendMessageReq
+	SendAt int64 `json:"send_at" binding:"required"` // 计划发送时间(ms)
+}
+
+// CreateScheduledMessage 创建定时消息，创建时按正常发送的规则校验一次，投递时会再校验
+func (s *MessageService) CreateScheduledMessage(ctx context.Context, req CreateScheduledMsgReq) (*model.ScheduledMessage, error) {
+	if err := checkScheduleTime(req.SendAt); err != nil {
+		return nil, err
+	}
+	if req.ConvType == constant.NotificationChatType {
+		return nil, errs.ErrInvalidParam.WithDetail("invalid conv type")
+	}
+	if err := s.ValidateSendMessage(ctx, &req.SendMessageReq); err != nil {
+		return nil, err
+	}
+	var pending int64
+	if err := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("sender_id = ? AND status = ?", req.SenderID, ScheduledMsgPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingScheduledMsgs {
+		return nil, errs.ErrInvalidParam.WithDetail("待发送的定时消息数量已达上限")
+	}
+	msg := &model.ScheduledMessage{
+		ID:        snowflake.GenID(),
+		SenderID:  req.SenderID,
+		ConvType:  req.ConvType,
+		TargetID:  req.TargetID,
+		MsgType:   req.MsgType,
+		Content:   req.Content,
+		AtUserIDs: req.AtUserIDs,
+		IsAtAll:   req.IsAtAll,
+		RefMsgID:  req.RefMsgID,
+		SendAt:    req.SendAt,
+		Status:    ScheduledMsgPending,
+	}
+	if err := s.db.WithContext(ctx).Create(msg).Error; err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+type GetScheduledMsgsReq struct {
+	PagedParams
+	UserID int64 `json:"user_id,string"`
+	// 为空时返回待发送的
+	Statuses []int32 `json:"statuses"`
+}
+
+// GetScheduledMessages 分页查询自己的定时消息，按计划发送时间升序
+func (s *MessageService) GetScheduledMessages(ctx context.Context, req GetScheduledMsgsReq) (PagedResp[*model.ScheduledMessage], error) {
+	if len(req.Statuses) == 0 {
+		req.Statuses = []int32{ScheduledMsgPending}
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	base := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("sender_id = ? AND status IN ?", req.UserID, req.Statuses)
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return PagedResp[*model.ScheduledMessage]{}, err
+	}
+	var msgs []*model.ScheduledMessage
+	if err := base.Session(&gorm.Session{}).
+		Order("send_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&msgs).Error; err != nil {
+		return PagedResp[*model.ScheduledMessage]{}, err
+	}
+	return PagedResp[*model.ScheduledMessage]{
+		Page:     page,
+		Total:    int(total),
+		PageSize: pageSize,
+		Data:     msgs,
+	}, nil
+}
+
+type UpdateScheduledMsgReq struct {
+	UserID    int64   `json:"user_id,string"`
+	ID        int64   `json:"id,string" binding:"required"`
+	MsgType   int32   `json:"msg_type"`
+	Content   string  `json:"content"`
+	AtUserIDs []int64 `json:"at_user_ids"`
+	IsAtAll   bool    `json:"is_at_all"`
+	SendAt    int64   `json:"send_at"`
+}
+
+// UpdateScheduledMessage 修改待发送的定时消息的内容或发送时间，未传的字段保持不变（@信息随内容一起更新）
+func (s *MessageService) UpdateScheduledMessage(ctx context.Context, req UpdateScheduledMsgReq) (*model.ScheduledMessage, error) {
+	msg, err := s.getOwnScheduledMessage(ctx, req.UserID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Status != ScheduledMsgPending {
+		return nil, errs.ErrScheduledMsgNotPending
+	}
+	if req.SendAt != 0 {
+		if err := checkScheduleTime(req.SendAt); err != nil {
+			return nil, err
+		}
+		msg.SendAt = req.SendAt
+	}
+	if req.Content != "" {
+		msg.Content = req.Content
+		if req.MsgType != 0 {
+			msg.MsgType = req.MsgType
+		}
+		msg.AtUserIDs, msg.IsAtAll = req.AtUserIDs, req.IsAtAll
+	}
+	sendReq := scheduledToSendReq(msg)
+	if err := s.ValidateSendMessage(ctx, sendReq); err != nil {
+		return nil, err
+	}
+	msg.AtUserIDs = sendReq.AtUserIDs
+	res := s.db.WithContext(ctx).Model(msg).
+		Where("status = ?", ScheduledMsgPending).
+		Select("msg_type", "content", "at_user_ids", "is_at_all", "send_at").
+		Updates(msg)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	// 更新期间被定时任务领取了
+	if res.RowsAffected == 0 {
+		return nil, errs.ErrScheduledMsgNotPending
+	}
+	return msg, nil
+}
+
+type CancelScheduledMsgReq struct {
+	UserID int64 `json:"user_id,string"`
+	ID     int64 `json:"id,string" binding:"required"`
+}
+
+// CancelScheduledMessage 取消待发送的定时消息
+func (s *MessageService) CancelScheduledMessage(ctx context.Context, req CancelScheduledMsgReq) error {
+	if _, err := s.getOwnScheduledMessage(ctx, req.UserID, req.ID); err != nil {
+		return err
+	}
+	res := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", req.ID, ScheduledMsgPending).
+		Update("status", ScheduledMsgCanceled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrScheduledMsgNotPending
+	}
+	return nil
+}
+
+func (s *MessageService) getOwnScheduledMessage(ctx context.Context, userID, id int64) (*model.ScheduledMessage, error) {
+	var msg model.ScheduledMessage
+	if err := s.db.WithContext(ctx).Where("id = ? AND sender_id = ?", id, userID).First(&msg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.ErrMsgNotFound
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DispatchDueScheduledMessages 领取并投递到期的定时消息，返回本次处理的条数。
+// 领取用带状态条件的 UPDATE 保证多实例下每条只被一个实例领取；
+// 投递使用固定的 ClientMsgID，实例崩溃后重新领取也不会重复发送。
+func (s *MessageService) DispatchDueScheduledMessages(ctx context.Context, producer *MsgProducer, instanceID string, limit int) (int, error) {
+	now := time.Now()
+	// 回收崩溃实例领取后未完成的消息
+	if err := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("status = ? AND claimed_at < ?", ScheduledMsgSending, now.Add(-scheduledMsgClaimTimeout).UnixMilli()).
+		Update("status", ScheduledMsgPending).Error; err != nil {
+		return 0, err
+	}
+	var due []*model.ScheduledMessage
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND send_at <= ?", ScheduledMsgPending, now.UnixMilli()).
+		Order("send_at ASC").
+		Limit(limit).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+	var handled int
+	for _, msg := range due {
+		res := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+			Where("id = ? AND status = ?", msg.ID, ScheduledMsgPending).
+			Updates(map[string]interface{}{
+				"status":     ScheduledMsgSending,
+				"claimed_by": instanceID,
+				"claimed_at": time.Now().UnixMilli(),
+			})
+		if res.Error != nil {
+			return handled, res.Error
+		}
+		if res.RowsAffected == 0 {
+			// 已被其他实例领取或已取消
+			continue
+		}
+		handled++
+		s.dispatchScheduledMessage(ctx, producer, msg)
+	}
+	return handled, nil
+}
+
+func (s *MessageService) dispatchScheduledMessage(ctx context.Context, producer *MsgProducer, msg *model.ScheduledMessage) {
+	resp, err := producer.Send(ctx, scheduledToSendReq(msg))
+	updates := map[string]interface{}{}
+	var codeErr *errs.CodeError
+	switch {
+	case err == nil:
+		updates["status"] = ScheduledMsgSent
+		updates["server_msg_id"] = resp.ServerMsgID
+	case errors.As(err, &codeErr) || msg.RetryCount+1 >= maxScheduledMsgRetry:
+		// 校验失败（如已退群、被拉黑）不再重试
+		updates["status"] = ScheduledMsgFailed
+		updates["err_msg"] = truncateRunes(err.Error(), 255)
+	default:
+		updates["status"] = ScheduledMsgPending
+		updates["retry_count"] = gorm.Expr("retry_count + 1")
+		updates["err_msg"] = truncateRunes(err.Error(), 255)
+	}
+	if err != nil {
+		log.Printf("dispatchScheduledMessage id=%d error: %v", msg.ID, err)
+	}
+	if err := s.db.WithContext(ctx).Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", msg.ID, ScheduledMsgSending).
+		Updates(updates).Error; err != nil {
+		log.Printf("dispatchScheduledMessage update id=%d error: %v", msg.ID, err)
+	}
+}
+
+func scheduledToSendReq(msg *model.ScheduledMessage) *SendMessageReq {
+	return &SendMessageReq{
+		SenderID:    msg.SenderID,
+		ConvType:    msg.ConvType,
+		TargetID:    msg.TargetID,
+		MsgType:     msg.MsgType,
+		ClientMsgID: "sched-" + strconv.FormatInt(msg.ID, 10),
+		Content:     msg.Content,
+		AtUserIDs:   msg.AtUserIDs,
+		IsAtAll:     msg.IsAtAll,
+		RefMsgID:    msg.RefMsgID,
+	}
+}
+
+func checkScheduleTime(sendAt int64) error {
+	now := time.Now()
+	if sendAt <= now.UnixMilli() {
+		return errs.ErrInvalidParam.WithDetail("发送时间必须晚于当前时间")
+	}
+	if sendAt > now.Add(maxScheduleAhead).UnixMilli() {
+		return errs.ErrInvalidParam.WithDetail("发送时间超出可定时范围")
+	}
+	return nil
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
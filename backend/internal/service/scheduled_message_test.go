@@ -0,0 +1,65 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/snowflake"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestScheduledMessageLifecycle(t *testing.T) {
+	_ = snowflake.Init(snowflake.Config{MachineID: 1})
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.ScheduledMessage{}, &model.GroupMember{}, &model.ConversationSetting{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+
+	sendAt := time.Now().Add(time.Hour).UnixMilli()
+	_, err = s.CreateScheduledMessage(ctx, CreateScheduledMsgReq{
+		SendMessageReq: SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"hi"}`},
+		SendAt:         time.Now().Add(-time.Minute).UnixMilli(),
+	})
+	if err == nil {
+		t.Fatal("expected error for past send time")
+	}
+	msg, err := s.CreateScheduledMessage(ctx, CreateScheduledMsgReq{
+		SendMessageReq: SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeText, Content: `{"content":"hi"}`},
+		SendAt:         sendAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledMessage failed: %v", err)
+	}
+
+	if _, err := s.UpdateScheduledMessage(ctx, UpdateScheduledMsgReq{UserID: 2, ID: msg.ID, Content: `{"content":"x"}`}); !errors.Is(err, errs.ErrMsgNotFound) {
+		t.Fatalf("other user update err = %v", err)
+	}
+	if _, err := s.UpdateScheduledMessage(ctx, UpdateScheduledMsgReq{UserID: 1, ID: msg.ID, Content: `{"content":"hello"}`}); err != nil {
+		t.Fatalf("UpdateScheduledMessage failed: %v", err)
+	}
+	list, err := s.GetScheduledMessages(ctx, GetScheduledMsgsReq{UserID: 1})
+	if err != nil || list.Total != 1 || list.Data[0].Content != `{"content":"hello"}` || list.Data[0].SendAt != sendAt {
+		t.Fatalf("unexpected list: %+v, err: %v", list, err)
+	}
+
+	if err := s.CancelScheduledMessage(ctx, CancelScheduledMsgReq{UserID: 1, ID: msg.ID}); err != nil {
+		t.Fatalf("CancelScheduledMessage failed: %v", err)
+	}
+	if err := s.CancelScheduledMessage(ctx, CancelScheduledMsgReq{UserID: 1, ID: msg.ID}); !errors.Is(err, errs.ErrScheduledMsgNotPending) {
+		t.Fatalf("cancel twice err = %v", err)
+	}
+	if _, err := s.UpdateScheduledMessage(ctx, UpdateScheduledMsgReq{UserID: 1, ID: msg.ID, SendAt: sendAt}); !errors.Is(err, errs.ErrScheduledMsgNotPending) {
+		t.Fatalf("update canceled err = %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"backend/internal/api/apiresp/errs"
 	"backend/internal/dto"
 	"backend/internal/model"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/msgcontent"
 	"backend/pkg/util"
 	"context"
 	"errors"
@@ -121,7 +123,14 @@ func (u *UserService) UpdateUserInfo(ctx context.Context, req UpdateUserInfoReq,
 	if req.Ex != "" {
 		user.Ex = req.Ex
 	}
-	return u.db.WithContext(ctx).Save(&user).Error
+	if err := u.db.WithContext(ctx).Save(&user).Error; err != nil {
+		return err
+	}
+	sendNotification(ctx, userId, []int64{userId}, constant.MsgTypeAccountNotification, &msgcontent.AccountElem{
+		Event: msgcontent.AccountEventInfoUpdated,
+		Time:  time.Now().UnixMilli(),
+	})
+	return nil
 }
 
 func (u *UserService) GetUsersPublicInfo(ctx context.Context, userId int64) (dto.UserInfo, error) {
@@ -135,6 +144,18 @@ func (u *UserService) GetUsersPublicInfo(ctx context.Context, userId int64) (dto
 	return dto.ConvertToUserInfo(user), nil
 }
 
+// IsAppManager 判断用户是否为应用管理员，可调用批量发送、业务通知等后台接口
+func (u *UserService) IsAppManager(ctx context.Context, userId int64) (bool, error) {
+	var user model.User
+	if err := u.db.WithContext(ctx).Select("app_manager_level").First(&user, "user_id = ?", userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errs.ErrUserNotFound
+		}
+		return false, err
+	}
+	return user.AppManagerLevel >= constant.AppAdmin, nil
+}
+
 type UserLoginReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -155,5 +176,9 @@ func (u *UserService) UserLogin(ctx context.Context, req UserLoginReq) (string,
 	if err != nil {
 		return "", err
 	}
+	sendNotification(ctx, user.UserID, []int64{user.UserID}, constant.MsgTypeAccountNotification, &msgcontent.AccountElem{
+		Event: msgcontent.AccountEventLogin,
+		Time:  time.Now().UnixMilli(),
+	})
 	return token, nil
 }
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"backend/internal/model"
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPullConvListNum = 100
+	maxPullConvListNum     = 500
+	// 时间线 seq 行每批锁定、写回的用户数
+	userTimelineSeqBatch = 1000
+)
+
+// AppendUserTimelines 将已落库的消息写入所有接收者(含发送者，用于多端同步)的时间线。
+// 每个用户的时间线 seq 全局递增，客户端只需保存一个游标即可增量同步所有会话。
+// 分发协程按批次并发写入，seq 的分配和时间线的写入放在同一个事务中并锁住用户的 seq 行，
+// 同一用户的时间线按 seq 顺序提交，客户端按 seq > cursor 拉取不会跳过晚提交的记录。
+func (s *MessageService) AppendUserTimelines(ctx context.Context, msgs []*model.Message) error {
+	recipients := make(map[string][]int64)
+	for _, msg := range msgs {
+		if _, ok := recipients[msg.ConversationID]; ok || len(msg.RecvIDs) > 0 {
+			continue
+		}
+		userIDs, err := s.getConversationMemberIDs(ctx, msg.ConversationID)
+		if err != nil {
+			return err
+		}
+		recipients[msg.ConversationID] = uniqueIDs(userIDs)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		timelines, err := buildUserTimelines(msgs, recipients, func(counts map[int64]int64) (map[int64]int64, error) {
+			return mallocUserTimelineSeqs(tx, counts)
+		})
+		if err != nil {
+			return err
+		}
+		if len(timelines) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(timelines, 500).Error
+	})
+}
+
+// mallocUserTimelineSeqs 为每个用户分配 counts[userID] 个时间线 seq，返回各用户分配前的最大 seq。
+// 按用户ID升序分批锁住 seq 行并批量写回，行锁持有到事务提交，同一用户的并发写入在这里排队；
+// 固定的加锁顺序避免并发事务互相死锁。
+func mallocUserTimelineSeqs(tx *gorm.DB, counts map[int64]int64) (map[int64]int64, error) {
+	if len(counts) == 0 {
+		return map[int64]int64{}, nil
+	}
+	userIDs := make([]int64, 0, len(counts))
+	for userID := range counts {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	seqs := make([]*model.SeqUserTimeline, 0, len(userIDs))
+	for _, userID := range userIDs {
+		seqs = append(seqs, &model.SeqUserTimeline{OwnerID: userID})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(seqs, userTimelineSeqBatch).Error; err != nil {
+		return nil, err
+	}
+	maxSeqs := make(map[int64]int64, len(userIDs))
+	for i := 0; i < len(userIDs); i += userTimelineSeqBatch {
+		batch := userIDs[i:min(i+userTimelineSeqBatch, len(userIDs))]
+		var rows []model.SeqUserTimeline
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("owner_id IN ?", batch).Order("owner_id").
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			maxSeqs[row.OwnerID] = row.MaxSeq
+		}
+	}
+	for _, seq := range seqs {
+		seq.MaxSeq = maxSeqs[seq.OwnerID] + counts[seq.OwnerID]
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_seq"}),
+	}).CreateInBatches(seqs, userTimelineSeqBatch).Error; err != nil {
+		return nil, err
+	}
+	return maxSeqs, nil
+}
+
+// buildUserTimelines 按用户统计需要的 seq 数量，一次分配后依消息顺序填充。
+// malloc 返回各用户分配前的最大 seq。
+func buildUserTimelines(msgs []*model.Message, recipients map[string][]int64, malloc func(counts map[int64]int64) (map[int64]int64, error)) ([]*model.UserTimeline, error) {
+	counts := make(map[int64]int64)
+	for _, msg := range msgs {
+		for _, userID := range msgRecipients(msg, recipients) {
+			counts[userID]++
+		}
+	}
+	nextSeq, err := malloc(counts)
+	if err != nil {
+		return nil, err
+	}
+	timelines := make([]*model.UserTimeline, 0)
+	for _, msg := range msgs {
+		snapshot := msgSnapshot(msg.MsgType, msg.Content)
+		for _, userID := range msgRecipients(msg, recipients) {
+			nextSeq[userID]++
+			timelines = append(timelines, &model.UserTimeline{
+				OwnerID:        userID,
+				Seq:            nextSeq[userID],
+				ConversationID: msg.ConversationID,
+				MsgID:          msg.ID,
+				RefMsgSeq:      msg.Seq,
+				MsgType:        msg.MsgType,
+				SenderID:       msg.SenderID,
+				Snapshot:       snapshot,
+			})
+		}
+	}
+	return timelines, nil
+}
+
+// msgRecipients 指定了接收者的消息(如退群、解散事件)只写入这些用户的时间线
+func msgRecipients(msg *model.Message, recipients map[string][]int64) []int64 {
+	if len(msg.RecvIDs) > 0 {
+		return uniqueIDs(msg.RecvIDs)
+	}
+	return recipients[msg.ConversationID]
+}
+
+// pullConvListNum 修正单次拉取条数
+func pullConvListNum(num int) int {
+	if num <= 0 {
+		return defaultPullConvListNum
+	}
+	if num > maxPullConvListNum {
+		return maxPullConvListNum
+	}
+	return num
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"backend/internal/model"
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBuildUserTimelines(t *testing.T) {
+	msgs := []*model.Message{
+		{ID: 1, ConversationID: "single:1_2", Seq: 10, SenderID: 1, MsgType: 101, Content: `{"content":"hi"}`},
+		{ID: 2, ConversationID: "group:9", Seq: 5, SenderID: 3, MsgType: 101, Content: `{"content":"hello"}`},
+		{ID: 3, ConversationID: "single:1_2", Seq: 11, SenderID: 2, MsgType: 101, Content: `{"content":"yo"}`},
+	}
+	recipients := map[string][]int64{
+		"single:1_2": {1, 2},
+		"group:9":    {1, 3},
+	}
+	maxSeqs := map[int64]int64{1: 100}
+	timelines, err := buildUserTimelines(msgs, recipients, func(counts map[int64]int64) (map[int64]int64, error) {
+		seqs := make(map[int64]int64, len(counts))
+		for userID, size := range counts {
+			seqs[userID] = maxSeqs[userID]
+			maxSeqs[userID] += size
+		}
+		return seqs, nil
+	})
+	if err != nil {
+		t.Fatalf("buildUserTimelines error: %v", err)
+	}
+	if len(timelines) != 6 {
+		t.Fatalf("len = %d, want 6", len(timelines))
+	}
+	var user1 []int64
+	for _, tl := range timelines {
+		if tl.OwnerID == 1 {
+			user1 = append(user1, tl.Seq)
+		}
+	}
+	if len(user1) != 3 || user1[0] != 101 || user1[1] != 102 || user1[2] != 103 {
+		t.Fatalf("user1 seqs = %v, want [101 102 103]", user1)
+	}
+	if maxSeqs[1] != 103 || maxSeqs[3] != 1 {
+		t.Fatalf("max seqs = %v", maxSeqs)
+	}
+	if timelines[0].RefMsgSeq != 10 || timelines[0].Snapshot == "" {
+		t.Fatalf("timeline = %+v", timelines[0])
+	}
+
+	// 指定了接收者的群事件只写入接收者的时间线，被移出的成员也能收到
+	kicked := []*model.Message{{ID: 4, ConversationID: "group:9", Seq: 6, SenderID: 3, MsgType: 304, RecvIDs: []int64{3, 4}}}
+	timelines, err = buildUserTimelines(kicked, recipients, func(counts map[int64]int64) (map[int64]int64, error) {
+		return map[int64]int64{}, nil
+	})
+	if err != nil {
+		t.Fatalf("buildUserTimelines error: %v", err)
+	}
+	if len(timelines) != 2 || timelines[0].OwnerID != 3 || timelines[1].OwnerID != 4 {
+		t.Fatalf("timelines = %+v, want owners [3 4]", timelines)
+	}
+}
+
+func TestPullConvList(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.UserTimeline{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	for seq := int64(1); seq <= 3; seq++ {
+		db.Create(&model.UserTimeline{OwnerID: 1, Seq: seq, ConversationID: "single:1_2", MsgID: seq, RefMsgSeq: seq, MsgType: 101})
+	}
+	db.Create(&model.UserTimeline{OwnerID: 2, Seq: 1, ConversationID: "single:1_2", MsgID: 1, RefMsgSeq: 1, MsgType: 101})
+
+	resp, err := s.PullConvList(ctx, PullConvListReq{UserID: 1, Num: 2})
+	if err != nil {
+		t.Fatalf("PullConvList error: %v", err)
+	}
+	if len(resp.PullMsgs["single:1_2"]) != 2 || resp.UserSeq != 2 || resp.IsEnd {
+		t.Fatalf("resp = %+v", resp)
+	}
+	resp, err = s.PullConvList(ctx, PullConvListReq{UserID: 1, UserSeq: resp.UserSeq, Num: 2})
+	if err != nil {
+		t.Fatalf("PullConvList error: %v", err)
+	}
+	if len(resp.PullMsgs["single:1_2"]) != 1 || resp.UserSeq != 3 || !resp.IsEnd {
+		t.Fatalf("resp = %+v", resp)
+	}
+	// 没有新消息时游标不变
+	resp, err = s.PullConvList(ctx, PullConvListReq{UserID: 1, UserSeq: 3})
+	if err != nil || resp.UserSeq != 3 || !resp.IsEnd || len(resp.PullMsgs) != 0 {
+		t.Fatalf("resp = %+v, %v", resp, err)
+	}
+}
+
+func TestAppendUserTimelines(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.UserTimeline{}, &model.SeqUserTimeline{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	s := &MessageService{db: db}
+	ctx := context.Background()
+	for id := int64(1); id <= 3; id++ {
+		msg := &model.Message{ID: id, ConversationID: "group:9", Seq: id, SenderID: 1, MsgType: 101, Content: `{"content":"hi"}`, RecvIDs: []int64{1, 2}}
+		if err := s.AppendUserTimelines(ctx, []*model.Message{msg}); err != nil {
+			t.Fatalf("AppendUserTimelines error: %v", err)
+		}
+	}
+	// 超过一批的接收者分批加锁和写回
+	recvIDs := make([]int64, 0, userTimelineSeqBatch+10)
+	for id := int64(1); id <= userTimelineSeqBatch+10; id++ {
+		recvIDs = append(recvIDs, id)
+	}
+	big := &model.Message{ID: 4, ConversationID: "group:9", Seq: 4, SenderID: 1, MsgType: 101, Content: `{"content":"hi"}`, RecvIDs: recvIDs}
+	if err := s.AppendUserTimelines(ctx, []*model.Message{big}); err != nil {
+		t.Fatalf("AppendUserTimelines error: %v", err)
+	}
+	var seqs []int64
+	db.Model(&model.UserTimeline{}).Where("owner_id = ?", 2).Order("seq").Pluck("seq", &seqs)
+	if len(seqs) != 4 || seqs[0] != 1 || seqs[3] != 4 {
+		t.Fatalf("user2 seqs = %v, want [1 2 3 4]", seqs)
+	}
+	// 时间线 seq 单独存放，不占用会话 seq 表
+	var seq model.SeqUserTimeline
+	if err := db.First(&seq, "owner_id = ?", 1).Error; err != nil || seq.MaxSeq != 4 {
+		t.Fatalf("seq = %+v, %v", seq, err)
+	}
+	var last model.SeqUserTimeline
+	if err := db.First(&last, "owner_id = ?", userTimelineSeqBatch+10).Error; err != nil || last.MaxSeq != 1 {
+		t.Fatalf("seq = %+v, %v", last, err)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/msgcontent"
+	"backend/internal/pkg/webhook"
+	"context"
+	"errors"
+	"time"
+)
+
+// callbackErr 把回调结果转换为返回给客户端的错误。
+// 业务方的错误码只有在自定义范围内才透传，避免回调返回未授权等系统错误码影响客户端
+func callbackErr(err error) error {
+	var rejectErr *webhook.RejectError
+	if errors.As(err, &rejectErr) {
+		if rejectErr.Code < errs.ErrCodeCallbackCustomBegin || rejectErr.Code > errs.ErrCodeCallbackCustomEnd {
+			if rejectErr.Msg == "" {
+				return errs.ErrCallbackRejected
+			}
+			return errs.ErrCallbackRejected.WithDetail(rejectErr.Msg)
+		}
+		msg := rejectErr.Msg
+		if msg == "" {
+			msg = errs.ErrCallbackRejected.Msg
+		}
+		return errs.NewCodeError(rejectErr.Code, msg)
+	}
+	if errors.Is(err, webhook.ErrCallbackFailed) {
+		return errs.ErrCallbackFailed
+	}
+	return err
+}
+
+func newCallbackSendMsgReq(req *SendMessageReq) webhook.BeforeSendMsgReq {
+	return webhook.BeforeSendMsgReq{
+		SenderID:       req.SenderID,
+		ConvType:       req.ConvType,
+		TargetID:       req.TargetID,
+		ConversationID: GetConversationID(req.ConvType, req.SenderID, req.TargetID),
+		MsgType:        req.MsgType,
+		Content:        req.Content,
+		ClientMsgID:    req.ClientMsgID,
+		AtUserIDs:      req.AtUserIDs,
+	}
+}
+
+// callbackBeforeSendMsg 发送前回调，业务方可以拒绝或改写消息内容。服务端产生的消息不回调
+func callbackBeforeSendMsg(ctx context.Context, req *SendMessageReq) error {
+	if req.serverSend || !webhook.Enabled(webhook.CommandBeforeSendMsg) {
+		return nil
+	}
+	var resp webhook.BeforeSendMsgResp
+	if err := webhook.Call(ctx, webhook.CommandBeforeSendMsg, newCallbackSendMsgReq(req), &resp); err != nil {
+		return callbackErr(err)
+	}
+	if resp.Content != nil && *resp.Content != req.Content {
+		if err := msgcontent.Validate(req.MsgType, *resp.Content); err != nil {
+			return errs.ErrMsgContentInvalid.WithDetail("callback content: " + err.Error())
+		}
+		req.Content = *resp.Content
+	}
+	return nil
+}
+
+// callbackAfterSendMsg 消息投递后异步通知业务方
+func callbackAfterSendMsg(req *SendMessageReq, serverMsgID int64) {
+	if req.serverSend {
+		return
+	}
+	webhook.CallAsync(webhook.CommandAfterSendMsg, webhook.AfterSendMsgReq{
+		BeforeSendMsgReq: newCallbackSendMsgReq(req),
+		ServerMsgID:      serverMsgID,
+		SendTime:         time.Now().UnixMilli(),
+	})
+}
+
+func callbackBeforeAddFriend(ctx context.Context, fromUserID int64, req ApplyToAddFriendReq) error {
+	var resp webhook.CommonResp
+	if err := webhook.Call(ctx, webhook.CommandBeforeAddFriend, webhook.BeforeAddFriendReq{
+		FromUserID: fromUserID,
+		ToUserID:   req.ToUserID,
+		ReqMsg:     req.ReqMsg,
+	}, &resp); err != nil {
+		return callbackErr(err)
+	}
+	return nil
+}
+
+func callbackBeforeJoinGroup(ctx context.Context, req JoinGroupReq) error {
+	var resp webhook.CommonResp
+	if err := webhook.Call(ctx, webhook.CommandBeforeJoinGroup, webhook.BeforeJoinGroupReq{
+		GroupID:       req.GroupID,
+		UserID:        req.UserID,
+		InviterUserID: req.InviterUserID,
+		JoinSource:    req.JoinSource,
+		ReqMsg:        req.ReqMsg,
+	}, &resp); err != nil {
+		return callbackErr(err)
+	}
+	return nil
+}
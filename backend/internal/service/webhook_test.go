@@ -0,0 +1,73 @@
+package service
+
+import (
+	"backend/internal/api/apiresp/errs"
+	"backend/internal/pkg/constant"
+	"backend/internal/pkg/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallbackBeforeSendMsg(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req webhook.BeforeSendMsgReq
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Content {
+		case `{"content":"reject"}`:
+			w.Write([]byte(`{"action_code":1,"err_msg":"not allowed"}`))
+		case `{"content":"invalid"}`:
+			w.Write([]byte(`{"action_code":0,"content":"not json"}`))
+		default:
+			w.Write([]byte(`{"action_code":0,"content":"{\"content\":\"modified\"}"}`))
+		}
+	}))
+	defer srv.Close()
+	defer webhook.Init(webhook.Config{})
+	webhook.Init(webhook.Config{URL: srv.URL, BeforeSendMsg: webhook.CallbackConfig{Enable: true}})
+	ctx := context.Background()
+	newReq := func(content string) *SendMessageReq {
+		return &SendMessageReq{SenderID: 1, ConvType: constant.SingleChatType, TargetID: 2, MsgType: constant.MsgTypeText, Content: content}
+	}
+
+	req := newReq(`{"content":"hello"}`)
+	if err := callbackBeforeSendMsg(ctx, req); err != nil || req.Content != `{"content":"modified"}` {
+		t.Fatalf("modify = %s, %v", req.Content, err)
+	}
+	req = newReq(`{"content":"reject"}`)
+	var codeErr *errs.CodeError
+	if err := callbackBeforeSendMsg(ctx, req); !errors.As(err, &codeErr) || codeErr.Code != errs.ErrCodeCallbackRejected {
+		t.Fatalf("reject err = %v", err)
+	}
+	req = newReq(`{"content":"invalid"}`)
+	if err := callbackBeforeSendMsg(ctx, req); !errors.As(err, &codeErr) || codeErr.Code != errs.ErrMsgContentInvalid.Code || req.Content != `{"content":"invalid"}` {
+		t.Fatalf("invalid content = %s, %v", req.Content, err)
+	}
+	// 服务端产生的系统消息不回调
+	req = newReq(`{"group_id":"1","operator_user_id":"1","group_name":"g"}`)
+	req.MsgType, req.serverSend = constant.MsgTypeGroupCreated, true
+	if err := callbackBeforeSendMsg(ctx, req); err != nil || req.Content != `{"group_id":"1","operator_user_id":"1","group_name":"g"}` {
+		t.Fatalf("system msg = %s, %v", req.Content, err)
+	}
+}
+
+func TestCallbackErr(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{&webhook.RejectError{Msg: "no"}, errs.ErrCodeCallbackRejected},
+		{&webhook.RejectError{Code: 50001, Msg: "forbidden"}, 50001},
+		// 系统错误码不透传，否则会让客户端误以为需要重新登录
+		{&webhook.RejectError{Code: errs.ErrCodeUnauthorized}, errs.ErrCodeCallbackRejected},
+		{webhook.ErrCallbackFailed, errs.ErrCodeCallbackFailed},
+	}
+	for _, c := range cases {
+		if got := toCodeError(callbackErr(c.err)).Code; got != c.code {
+			t.Fatalf("callbackErr(%v) = %d, want %d", c.err, got, c.code)
+		}
+	}
+}
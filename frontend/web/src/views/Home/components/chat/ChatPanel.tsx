@@ -9,6 +9,7 @@ import { IconButton } from '../common'
 import { useMessageContext } from '../../hooks/useMessageContext'
 import { useUserStore } from '@/store/userStore'
 import { toast } from 'sonner'
+import { MSG_TYPE_TEXT } from '../../utils/formatters'
 
 interface ChatPanelProps {
   themeColor: string
@@ -106,8 +107,8 @@ export function ChatPanel({
           sender_id: String(user.id),
           conv_type: convTypeNum,
           target_id: targetId,
-          msg_type: 1, // 文本消息
-          content,
+          msg_type: MSG_TYPE_TEXT,
+          content: JSON.stringify({ content }),
         },
         conversation.id // 传入会话 ID
       )
@@ -4,6 +4,7 @@ import { getFriendById } from '@/modules/friend'
 import { useUserStore } from '@/store/userStore'
 import { useMessageContext } from './useMessageContext'
 import type { ConversationItem } from '../types'
+import { getMessageText } from '../utils/formatters'
 
 interface ConversationsState {
   conversations: ConversationItem[]
@@ -94,7 +95,7 @@ export function useConversations() {
               title: friend.friendUser.nickname || friend.friendUser.username,
               avatar: friend.friendUser.nickname?.slice(0, 2).toUpperCase() || 'U',
               accent: generateAccentColor(convId),
-              lastMessage: lastMsg?.content || '暂无消息',
+              lastMessage: lastMsg ? getMessageText(lastMsg) : '暂无消息',
               time: formatTime(lastMsg?.send_time || lastMsg?.create_time),
               unread: 0, // TODO: 后续接入未读数
               muted: false,
@@ -133,7 +134,7 @@ export function useConversations() {
         const messages = conversationMessages[conv.id] || []
         const lastMsg = messages[messages.length - 1]
         if (lastMsg) {
-          conv.lastMessage = lastMsg.content
+          conv.lastMessage = getMessageText(lastMsg)
           conv.time = formatTime(lastMsg.send_time || lastMsg.create_time)
         }
       })
@@ -12,7 +12,7 @@ import { useClickOutside } from '../hooks/useClickOutside'
 import { useResponsive } from '../hooks/useResponsive'
 import { useConversations, useMessageContext } from '../hooks'
 import { useUserStore } from '@/store/userStore'
-import { adaptMessageToItem } from '../utils/formatters'
+import { adaptMessageToItem, getMessageText } from '../utils/formatters'
 import type { ConversationItem } from '../types'
 
 interface LayoutContext {
@@ -78,7 +78,7 @@ export function ChatPage() {
   useEffect(() => {
     setOnNewMessage((msg) => {
       if (msg.sender_id !== user.id) {
-        toast.success(`收到新消息: ${msg.content.slice(0, 20)}...`)
+        toast.success(`收到新消息: ${getMessageText(msg).slice(0, 20)}...`)
       }
     })
   }, [setOnNewMessage])
@@ -1,6 +1,9 @@
 import type { Message } from '@/modules'
 import type { MessageItem } from '../types'
 
+// 文本消息类型，content 为 {"content": "..."}
+export const MSG_TYPE_TEXT = 101
+
 export function getInitials(name: string, length: number = 2): string {
   return name.slice(0, length).toUpperCase()
 }
@@ -55,6 +58,19 @@ export function formatTimestamp(timestamp?: number): string {
   return date.toLocaleDateString('zh-CN', { year: 'numeric', month: '2-digit', day: '2-digit' })
 }
 
+/**
+ * 取消息的展示文本：文本消息 content 为 {"content": "..."}，旧消息为纯文本
+ */
+export function getMessageText(msg: Pick<Message, 'msg_type' | 'content'>): string {
+  if (msg.msg_type !== MSG_TYPE_TEXT) return msg.content
+  try {
+    const elem = JSON.parse(msg.content) as { content?: string }
+    return typeof elem.content === 'string' ? elem.content : msg.content
+  } catch {
+    return msg.content
+  }
+}
+
 /**
  * 将后端 Message 适配为前端 MessageItem
  */
@@ -64,7 +80,7 @@ export function adaptMessageToItem(msg: Message, currentUserId: string | number)
   return {
     id: msg.id,
     author: isMine ? '我' : msg.sender_id,
-    content: msg.content,
+    content: getMessageText(msg),
     timestamp: formatTimestamp(msg.send_time || msg.create_time),
     direction: isMine ? 'out' : 'in',
     status: msg.status, // 1: 发送中, 2: 已发送, 3: 失败